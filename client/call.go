@@ -7,9 +7,11 @@ import (
 	"fmt"
 	"strconv"
 	"sync/atomic"
+	"time"
 
 	"github.com/ThinkInAIXYZ/go-mcp/pkg"
 	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+	"github.com/ThinkInAIXYZ/go-mcp/transport"
 )
 
 func (client *Client) initialization(ctx context.Context, request *protocol.InitializeRequest) (*protocol.InitializeResult, error) {
@@ -24,8 +26,14 @@ func (client *Client) initialization(ctx context.Context, request *protocol.Init
 		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	// the server may answer with an older revision than requested, any revision known to the client is accepted
 	if _, ok := protocol.SupportedVersion[result.ProtocolVersion]; !ok {
-		return nil, fmt.Errorf("protocol version not supported, supported lastest version is %v", protocol.Version)
+		return nil, fmt.Errorf("protocol version %s not supported, supported lastest version is %v", result.ProtocolVersion, protocol.Version)
+	}
+
+	// transports that send the version with every message need it before the initialized notification
+	if t, ok := client.transport.(transport.ProtocolVersionSetter); ok {
+		t.SetProtocolVersion(result.ProtocolVersion)
 	}
 
 	if err = client.sendNotification4Initialized(ctx); err != nil {
@@ -38,13 +46,14 @@ func (client *Client) initialization(ctx context.Context, request *protocol.Init
 	client.serverInfo = &result.ServerInfo
 	client.serverCapabilities = &result.Capabilities
 	client.serverInstructions = result.Instructions
+	client.protocolVersion = result.ProtocolVersion
 
 	client.ready.Store(true)
 	return &result, nil
 }
 
-func (client *Client) Ping(ctx context.Context, request *protocol.PingRequest) (*protocol.PingResult, error) {
-	response, err := client.callServer(ctx, protocol.Ping, request)
+func (client *Client) Ping(ctx context.Context, request *protocol.PingRequest, opts ...CallOption) (*protocol.PingResult, error) {
+	response, err := client.callServer(ctx, protocol.Ping, request, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -56,12 +65,28 @@ func (client *Client) Ping(ctx context.Context, request *protocol.PingRequest) (
 	return &result, nil
 }
 
-func (client *Client) ListPrompts(ctx context.Context) (*protocol.ListPromptsResult, error) {
+func (client *Client) ListPrompts(ctx context.Context, opts ...CallOption) (*protocol.ListPromptsResult, error) {
+	return client.ListPromptsPage(ctx, protocol.NewListPromptsRequest(), opts...)
+}
+
+// ListPromptsAll follows NextCursor until every prompt of the server has been listed
+func (client *Client) ListPromptsAll(ctx context.Context, opts ...CallOption) ([]protocol.Prompt, error) {
+	return listAll(func(cursor string) ([]protocol.Prompt, string, error) {
+		result, err := client.ListPromptsPage(ctx, protocol.NewListPromptsRequest().WithCursor(cursor), opts...)
+		if err != nil {
+			return nil, "", err
+		}
+		return result.Prompts, result.NextCursor, nil
+	})
+}
+
+// ListPromptsPage lists the page of prompts request.Cursor points to, the first page when it is empty
+func (client *Client) ListPromptsPage(ctx context.Context, request *protocol.ListPromptsRequest, opts ...CallOption) (*protocol.ListPromptsResult, error) {
 	if client.serverCapabilities.Prompts == nil {
 		return nil, pkg.ErrServerNotSupport
 	}
 
-	response, err := client.callServer(ctx, protocol.PromptsList, protocol.NewListPromptsRequest())
+	response, err := client.callServer(ctx, protocol.PromptsList, request, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -73,12 +98,12 @@ func (client *Client) ListPrompts(ctx context.Context) (*protocol.ListPromptsRes
 	return &result, nil
 }
 
-func (client *Client) GetPrompt(ctx context.Context, request *protocol.GetPromptRequest) (*protocol.GetPromptResult, error) {
+func (client *Client) GetPrompt(ctx context.Context, request *protocol.GetPromptRequest, opts ...CallOption) (*protocol.GetPromptResult, error) {
 	if client.serverCapabilities.Prompts == nil {
 		return nil, pkg.ErrServerNotSupport
 	}
 
-	response, err := client.callServer(ctx, protocol.PromptsGet, request)
+	response, err := client.callServer(ctx, protocol.PromptsGet, request, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -91,12 +116,28 @@ func (client *Client) GetPrompt(ctx context.Context, request *protocol.GetPrompt
 	return &result, nil
 }
 
-func (client *Client) ListResources(ctx context.Context) (*protocol.ListResourcesResult, error) {
+func (client *Client) ListResources(ctx context.Context, opts ...CallOption) (*protocol.ListResourcesResult, error) {
+	return client.ListResourcesPage(ctx, protocol.NewListResourcesRequest(), opts...)
+}
+
+// ListResourcesAll follows NextCursor until every resource of the server has been listed
+func (client *Client) ListResourcesAll(ctx context.Context, opts ...CallOption) ([]protocol.Resource, error) {
+	return listAll(func(cursor string) ([]protocol.Resource, string, error) {
+		result, err := client.ListResourcesPage(ctx, protocol.NewListResourcesRequest().WithCursor(cursor), opts...)
+		if err != nil {
+			return nil, "", err
+		}
+		return result.Resources, result.NextCursor, nil
+	})
+}
+
+// ListResourcesPage lists the page of resources request.Cursor points to, the first page when it is empty
+func (client *Client) ListResourcesPage(ctx context.Context, request *protocol.ListResourcesRequest, opts ...CallOption) (*protocol.ListResourcesResult, error) {
 	if client.serverCapabilities.Resources == nil {
 		return nil, pkg.ErrServerNotSupport
 	}
 
-	response, err := client.callServer(ctx, protocol.ResourcesList, protocol.NewListResourcesRequest())
+	response, err := client.callServer(ctx, protocol.ResourcesList, request, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -108,12 +149,28 @@ func (client *Client) ListResources(ctx context.Context) (*protocol.ListResource
 	return &result, err
 }
 
-func (client *Client) ListResourceTemplates(ctx context.Context) (*protocol.ListResourceTemplatesResult, error) {
+func (client *Client) ListResourceTemplates(ctx context.Context, opts ...CallOption) (*protocol.ListResourceTemplatesResult, error) {
+	return client.ListResourceTemplatesPage(ctx, protocol.NewListResourceTemplatesRequest(), opts...)
+}
+
+// ListResourceTemplatesAll follows NextCursor until every resource template of the server has been listed
+func (client *Client) ListResourceTemplatesAll(ctx context.Context, opts ...CallOption) ([]protocol.ResourceTemplate, error) {
+	return listAll(func(cursor string) ([]protocol.ResourceTemplate, string, error) {
+		result, err := client.ListResourceTemplatesPage(ctx, protocol.NewListResourceTemplatesRequest().WithCursor(cursor), opts...)
+		if err != nil {
+			return nil, "", err
+		}
+		return result.ResourceTemplates, result.NextCursor, nil
+	})
+}
+
+// ListResourceTemplatesPage lists the page of resource templates request.Cursor points to, the first page when it is empty
+func (client *Client) ListResourceTemplatesPage(ctx context.Context, request *protocol.ListResourceTemplatesRequest, opts ...CallOption) (*protocol.ListResourceTemplatesResult, error) {
 	if client.serverCapabilities.Resources == nil {
 		return nil, pkg.ErrServerNotSupport
 	}
 
-	response, err := client.callServer(ctx, protocol.ResourceListTemplates, protocol.NewListResourceTemplatesRequest())
+	response, err := client.callServer(ctx, protocol.ResourceListTemplates, request, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -125,12 +182,12 @@ func (client *Client) ListResourceTemplates(ctx context.Context) (*protocol.List
 	return &result, nil
 }
 
-func (client *Client) ReadResource(ctx context.Context, request *protocol.ReadResourceRequest) (*protocol.ReadResourceResult, error) {
+func (client *Client) ReadResource(ctx context.Context, request *protocol.ReadResourceRequest, opts ...CallOption) (*protocol.ReadResourceResult, error) {
 	if client.serverCapabilities.Resources == nil {
 		return nil, pkg.ErrServerNotSupport
 	}
 
-	response, err := client.callServer(ctx, protocol.ResourcesRead, request)
+	response, err := client.callServer(ctx, protocol.ResourcesRead, request, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -142,12 +199,20 @@ func (client *Client) ReadResource(ctx context.Context, request *protocol.ReadRe
 	return &result, nil
 }
 
-func (client *Client) SubscribeResourceChange(ctx context.Context, request *protocol.SubscribeRequest) (*protocol.SubscribeResult, error) {
+// ResolveResourceLink reads the resource a ResourceLink content refers to
+func (client *Client) ResolveResourceLink(ctx context.Context, link *protocol.ResourceLink, opts ...CallOption) (*protocol.ReadResourceResult, error) {
+	if link == nil || link.URI == "" {
+		return nil, fmt.Errorf("%w: resource link without uri", pkg.ErrInvalidParams)
+	}
+	return client.ReadResource(ctx, protocol.NewReadResourceRequest(link.URI), opts...)
+}
+
+func (client *Client) SubscribeResourceChange(ctx context.Context, request *protocol.SubscribeRequest, opts ...CallOption) (*protocol.SubscribeResult, error) {
 	if client.serverCapabilities.Resources == nil || !client.serverCapabilities.Resources.Subscribe {
 		return nil, pkg.ErrServerNotSupport
 	}
 
-	response, err := client.callServer(ctx, protocol.ResourcesSubscribe, request)
+	response, err := client.callServer(ctx, protocol.ResourcesSubscribe, request, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -161,12 +226,12 @@ func (client *Client) SubscribeResourceChange(ctx context.Context, request *prot
 	return &result, nil
 }
 
-func (client *Client) UnSubscribeResourceChange(ctx context.Context, request *protocol.UnsubscribeRequest) (*protocol.UnsubscribeResult, error) {
+func (client *Client) UnSubscribeResourceChange(ctx context.Context, request *protocol.UnsubscribeRequest, opts ...CallOption) (*protocol.UnsubscribeResult, error) {
 	if client.serverCapabilities.Resources == nil || !client.serverCapabilities.Resources.Subscribe {
 		return nil, pkg.ErrServerNotSupport
 	}
 
-	response, err := client.callServer(ctx, protocol.ResourcesUnsubscribe, request)
+	response, err := client.callServer(ctx, protocol.ResourcesUnsubscribe, request, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -180,12 +245,28 @@ func (client *Client) UnSubscribeResourceChange(ctx context.Context, request *pr
 	return &result, nil
 }
 
-func (client *Client) ListTools(ctx context.Context) (*protocol.ListToolsResult, error) {
+func (client *Client) ListTools(ctx context.Context, opts ...CallOption) (*protocol.ListToolsResult, error) {
+	return client.ListToolsPage(ctx, protocol.NewListToolsRequest(), opts...)
+}
+
+// ListToolsAll follows NextCursor until every tool of the server has been listed
+func (client *Client) ListToolsAll(ctx context.Context, opts ...CallOption) ([]*protocol.Tool, error) {
+	return listAll(func(cursor string) ([]*protocol.Tool, string, error) {
+		result, err := client.ListToolsPage(ctx, protocol.NewListToolsRequest().WithCursor(cursor), opts...)
+		if err != nil {
+			return nil, "", err
+		}
+		return result.Tools, result.NextCursor, nil
+	})
+}
+
+// ListToolsPage lists the page of tools request.Cursor points to, the first page when it is empty
+func (client *Client) ListToolsPage(ctx context.Context, request *protocol.ListToolsRequest, opts ...CallOption) (*protocol.ListToolsResult, error) {
 	if client.serverCapabilities.Tools == nil {
 		return nil, pkg.ErrServerNotSupport
 	}
 
-	response, err := client.callServer(ctx, protocol.ToolsList, protocol.NewListToolsRequest())
+	response, err := client.callServer(ctx, protocol.ToolsList, request, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -197,12 +278,47 @@ func (client *Client) ListTools(ctx context.Context) (*protocol.ListToolsResult,
 	return &result, nil
 }
 
-func (client *Client) CallTool(ctx context.Context, request *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+type CallOption func(*callOptions)
+
+type callOptions struct {
+	progressHandler ProgressHandler
+	meta            map[string]interface{}
+}
+
+func newCallOptions(opts []CallOption) *callOptions {
+	options := &callOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	return options
+}
+
+// WithMeta attaches meta to the _meta of the request, on top of the _meta the request already carries.
+func WithMeta(meta map[string]interface{}) CallOption {
+	return func(o *callOptions) {
+		if o.meta == nil {
+			o.meta = make(map[string]interface{}, len(meta))
+		}
+		for k, v := range meta {
+			o.meta[k] = v
+		}
+	}
+}
+
+// WithProgressHandler asks the server to report the progress of the call, every notifications/progress is passed to handler.
+// Any request can carry it, whether progress is reported depends on the server handling that request.
+func WithProgressHandler(handler ProgressHandler) CallOption {
+	return func(o *callOptions) {
+		o.progressHandler = handler
+	}
+}
+
+func (client *Client) CallTool(ctx context.Context, request *protocol.CallToolRequest, opts ...CallOption) (*protocol.CallToolResult, error) {
 	if client.serverCapabilities.Tools == nil {
 		return nil, pkg.ErrServerNotSupport
 	}
 
-	response, err := client.callServer(ctx, protocol.ToolsCall, request)
+	response, err := client.callServer(ctx, protocol.ToolsCall, request, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -214,32 +330,186 @@ func (client *Client) CallTool(ctx context.Context, request *protocol.CallToolRe
 	return &result, nil
 }
 
+func (client *Client) Complete(ctx context.Context, request *protocol.CompleteRequest, opts ...CallOption) (*protocol.CompleteResult, error) {
+	if client.serverCapabilities.Completions == nil {
+		return nil, pkg.ErrServerNotSupport
+	}
+
+	response, err := client.callServer(ctx, protocol.CompletionComplete, request, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var result protocol.CompleteResult
+	if err := pkg.JSONUnmarshal(response, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return &result, nil
+}
+
+func (client *Client) SetLoggingLevel(ctx context.Context, level protocol.LoggingLevel, opts ...CallOption) (*protocol.SetLoggingLevelResult, error) {
+	if client.serverCapabilities.Logging == nil {
+		return nil, pkg.ErrServerNotSupport
+	}
+
+	response, err := client.callServer(ctx, protocol.LoggingSetLevel, protocol.NewSetLoggingLevelRequest(level), opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var result protocol.SetLoggingLevelResult
+	if len(response) > 0 {
+		if err = pkg.JSONUnmarshal(response, &result); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+		}
+	}
+	return &result, nil
+}
+
+// NotifyRootsChanged tells the server that the roots returned by the RootsProvider have changed
+func (client *Client) NotifyRootsChanged(ctx context.Context) error {
+	if client.clientCapabilities.Roots == nil || !client.clientCapabilities.Roots.ListChanged {
+		return pkg.ErrClientNotSupport
+	}
+	return client.sendMsgWithNotification(ctx, protocol.NotificationRootsListChanged, protocol.NewRootsListChangedNotification())
+}
+
+// listAll requests pages until the server stops returning a NextCursor
+func listAll[T any](listPage func(cursor string) ([]T, string, error)) ([]T, error) {
+	all := make([]T, 0)
+	cursor := ""
+	for {
+		items, nextCursor, err := listPage(cursor)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, items...)
+
+		if nextCursor == "" {
+			return all, nil
+		}
+		if nextCursor == cursor {
+			return nil, fmt.Errorf("server returned the same cursor %q twice", cursor)
+		}
+		cursor = nextCursor
+	}
+}
+
 func (client *Client) sendNotification4Initialized(ctx context.Context) error {
 	return client.sendMsgWithNotification(ctx, protocol.NotificationInitialized, protocol.NewInitializedNotification())
 }
 
 // Responsible for request and response assembly
-func (client *Client) callServer(ctx context.Context, method protocol.Method, params protocol.ClientRequest) (json.RawMessage, error) {
+func (client *Client) callServer(ctx context.Context, method protocol.Method, params protocol.ClientRequest, opts ...CallOption) (json.RawMessage, error) {
 	if !client.ready.Load() && (method != protocol.Initialize && method != protocol.Ping) {
 		return nil, errors.New("callServer: client not ready")
 	}
 
+	options := newCallOptions(opts)
+	if options.progressHandler != nil {
+		token := strconv.FormatInt(atomic.AddInt64(&client.progressToken, 1), 10)
+		client.progressHandlers.Set(token, options.progressHandler)
+		defer client.progressHandlers.Remove(token)
+
+		if options.meta == nil {
+			options.meta = make(map[string]interface{}, 1)
+		}
+		options.meta[protocol.ProgressTokenMetaKey] = token
+	}
+
+	// the meta is only merged into params when the request is sent, so that interceptors always see the typed request,
+	// it is set even when empty so that a call made from an interceptor doesn't inherit the meta of the outer call
+	ctx = context.WithValue(ctx, callMetaKey{}, options.meta)
+
+	return client.invoker(ctx, method, params)
+}
+
+type callMetaKey struct{}
+
+// invoke sends the request and waits for its response, it is the innermost Invoker of the interceptor chain
+func (client *Client) invoke(ctx context.Context, method protocol.Method, params protocol.ClientRequest) (json.RawMessage, error) {
+	if meta, _ := ctx.Value(callMetaKey{}).(map[string]interface{}); len(meta) > 0 {
+		var err error
+		if params, err = attachMeta(params, meta); err != nil {
+			return nil, fmt.Errorf("callServer: %w", err)
+		}
+	}
+
 	requestID := strconv.FormatInt(atomic.AddInt64(&client.requestID, 1), 10)
 	respChan := make(chan *protocol.JSONRPCResponse, 1)
 	client.reqID2respChan.Set(requestID, respChan)
 	defer client.reqID2respChan.Remove(requestID)
 
-	if err := client.sendMsgWithRequest(ctx, requestID, method, params); err != nil {
-		return nil, fmt.Errorf("callServer: %w", err)
+	// Some transports only return from Send after the response has been received,
+	// sending in the background lets a canceled ctx interrupt the wait.
+	sendErrChan := make(chan error, 1)
+	go func() {
+		defer pkg.Recover()
+
+		sendErrChan <- client.sendMsgWithRequest(ctx, requestID, method, params)
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if method != protocol.Initialize {
+				client.sendNotification4Cancelled(requestID, ctx.Err().Error())
+			}
+			return nil, ctx.Err()
+		case err := <-sendErrChan:
+			if err != nil {
+				return nil, fmt.Errorf("callServer: %w", err)
+			}
+			sendErrChan = nil
+		case response := <-respChan:
+			if err := response.Error; err != nil {
+				return nil, pkg.NewResponseError(err.Code, err.Message, err.Data)
+			}
+			return response.RawResult, nil
+		}
+	}
+}
+
+// attachMeta returns the JSON object of params with meta merged into its _meta
+func attachMeta(params protocol.ClientRequest, meta map[string]interface{}) (map[string]json.RawMessage, error) {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]json.RawMessage)
+	if string(raw) != "null" {
+		if err = pkg.JSONUnmarshal(raw, &fields); err != nil {
+			return nil, err
+		}
 	}
 
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	case response := <-respChan:
-		if err := response.Error; err != nil {
-			return nil, pkg.NewResponseError(err.Code, err.Message, err.Data)
+	merged := make(map[string]interface{}, len(meta))
+	if existing, ok := fields["_meta"]; ok {
+		if err = pkg.JSONUnmarshal(existing, &merged); err != nil {
+			return nil, err
 		}
-		return response.RawResult, nil
 	}
+	for k, v := range meta {
+		merged[k] = v
+	}
+
+	if fields["_meta"], err = json.Marshal(merged); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// sendNotification4Cancelled tells the server to stop handling a request the caller has given up on
+func (client *Client) sendNotification4Cancelled(requestID protocol.RequestID, reason string) {
+	go func() {
+		defer pkg.Recover()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := client.sendMsgWithNotification(ctx, protocol.NotificationCancelled, protocol.NewCancelledNotification(requestID, reason)); err != nil {
+			client.logger.Warnf("send cancelled notification fail: requestID=%v, err=%v", requestID, err)
+		}
+	}()
 }
@@ -21,12 +21,33 @@ func WithNotifyHandler(handler NotifyHandler) Option {
 	}
 }
 
+func WithLogHandler(handler LogHandler) Option {
+	return func(s *Client) {
+		s.logHandler = handler
+	}
+}
+
 func WithSamplingHandler(handler SamplingHandler) Option {
 	return func(s *Client) {
 		s.samplingHandler = handler
 	}
 }
 
+// WithElicitationHandler advertises the elicitation capability and answers the server's elicitation/create requests with handler.
+func WithElicitationHandler(handler ElicitationHandler) Option {
+	return func(s *Client) {
+		s.elicitationHandler = handler
+	}
+}
+
+// WithRootsProvider advertises the roots capability with listChanged and answers the server's roots/list requests with provider,
+// call NotifyRootsChanged when the roots it returns change.
+func WithRootsProvider(provider RootsProvider) Option {
+	return func(s *Client) {
+		s.rootsProvider = provider
+	}
+}
+
 func WithClientInfo(info protocol.Implementation) Option {
 	return func(s *Client) {
 		s.clientInfo = &info
@@ -50,10 +71,29 @@ type Client struct {
 
 	reqID2respChan cmap.ConcurrentMap[string, chan *protocol.JSONRPCResponse]
 
+	progressToken    int64
+	progressHandlers cmap.ConcurrentMap[string, ProgressHandler]
+
 	samplingHandler SamplingHandler
 
+	elicitationHandler ElicitationHandler
+
+	rootsProvider RootsProvider
+
 	notifyHandler NotifyHandler
 
+	logHandler LogHandler
+
+	methodHandlers       pkg.SyncMap[MethodHandlerFunc]
+	notificationHandlers pkg.SyncMap[NotificationHandlerFunc]
+
+	interceptors              []Interceptor
+	serverRequestInterceptors []ServerRequestInterceptor
+
+	// invoker and requestHandler are invoke and handleRequest wrapped by the interceptors
+	invoker        Invoker
+	requestHandler RequestHandler
+
 	requestID int64
 
 	ready            *pkg.AtomicBool
@@ -66,6 +106,9 @@ type Client struct {
 	serverInfo         *protocol.Implementation
 	serverInstructions string
 
+	// protocol version negotiated with the server
+	protocolVersion string
+
 	initTimeout time.Duration
 
 	closed chan struct{}
@@ -77,6 +120,7 @@ func NewClient(t transport.ClientTransport, opts ...Option) (*Client, error) {
 	client := &Client{
 		transport:          t,
 		reqID2respChan:     cmap.New[chan *protocol.JSONRPCResponse](),
+		progressHandlers:   cmap.New[ProgressHandler](),
 		ready:              pkg.NewAtomicBool(),
 		clientInfo:         &protocol.Implementation{},
 		clientCapabilities: &protocol.ClientCapabilities{},
@@ -90,16 +134,31 @@ func NewClient(t transport.ClientTransport, opts ...Option) (*Client, error) {
 		opt(client)
 	}
 
+	client.invoker = chainInterceptors(client.invoke, client.interceptors)
+	client.requestHandler = chainServerRequestInterceptors(client.handleRequest, client.serverRequestInterceptors)
+
 	if client.notifyHandler == nil {
 		h := NewBaseNotifyHandler()
 		h.Logger = client.logger
 		client.notifyHandler = h
 	}
 
+	if client.logHandler == nil {
+		client.logHandler = NewLoggerLogHandler(client.logger)
+	}
+
 	if client.samplingHandler != nil {
 		client.clientCapabilities.Sampling = struct{}{}
 	}
 
+	if client.elicitationHandler != nil {
+		client.clientCapabilities.Elicitation = struct{}{}
+	}
+
+	if client.rootsProvider != nil {
+		client.clientCapabilities.Roots = &protocol.RootsCapability{ListChanged: true}
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), client.initTimeout)
 	defer cancel()
 
@@ -142,6 +201,11 @@ func (client *Client) GetServerInstructions() string {
 	return client.serverInstructions
 }
 
+// GetProtocolVersion returns the protocol version the server chose during initialization
+func (client *Client) GetProtocolVersion() string {
+	return client.protocolVersion
+}
+
 func (client *Client) Close() error {
 	close(client.closed)
 
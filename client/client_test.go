@@ -4,10 +4,12 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/ThinkInAIXYZ/go-mcp/pkg"
 	"github.com/ThinkInAIXYZ/go-mcp/protocol"
@@ -95,6 +97,17 @@ func TestClientCall(t *testing.T) {
 				protocol.TextResourceContents{URI: "resource1", Text: "resource content", MimeType: "text/plain"},
 			}),
 		},
+		{
+			name: "test_resolve_resource_link",
+			f: func(client *Client, request protocol.ClientRequest) (protocol.ServerResponse, error) {
+				link := protocol.NewResourceLink(protocol.Resource{URI: request.(*protocol.ReadResourceRequest).URI, Name: "resource1"})
+				return client.ResolveResourceLink(context.Background(), link)
+			},
+			request: protocol.NewReadResourceRequest("resource1"),
+			expectedResponse: protocol.NewReadResourceResult([]protocol.ResourceContents{
+				protocol.BlobResourceContents{URI: "resource1", Blob: []byte("resource content"), MimeType: "application/octet-stream"},
+			}),
+		},
 		{
 			name: "test_list_resource_templates",
 			f: func(client *Client, _ protocol.ClientRequest) (protocol.ServerResponse, error) {
@@ -150,6 +163,44 @@ func TestClientCall(t *testing.T) {
 			}),
 			expectedResponse: protocol.NewCallToolResult([]protocol.Content{&protocol.TextContent{Type: "text", Text: "success"}}, false),
 		},
+		{
+			name: "test_call_tool_with_meta",
+			f: func(client *Client, _ protocol.ClientRequest) (protocol.ServerResponse, error) {
+				request := protocol.NewCallToolRequest("test_tool", map[string]interface{}{"a": 1})
+				request.Meta = map[string]interface{}{"traceparent": "00-trace"}
+				return client.CallTool(context.Background(), request, WithMeta(map[string]interface{}{"tenant": "t1"}))
+			},
+			request: &protocol.CallToolRequest{
+				Meta:      map[string]interface{}{"traceparent": "00-trace", "tenant": "t1"},
+				Name:      "test_tool",
+				Arguments: map[string]interface{}{"a": 1},
+			},
+			expectedResponse: protocol.NewCallToolResult([]protocol.Content{&protocol.TextContent{Type: "text", Text: "success"}}, false),
+		},
+		{
+			name: "test_list_tool_with_meta",
+			f: func(client *Client, _ protocol.ClientRequest) (protocol.ServerResponse, error) {
+				return client.ListTools(context.Background(), WithMeta(map[string]interface{}{"tenant": "t1"}))
+			},
+			request:          &protocol.ListToolsRequest{Meta: map[string]interface{}{"tenant": "t1"}},
+			expectedResponse: protocol.NewListToolsResult([]*protocol.Tool{}, ""),
+		},
+		{
+			name: "test_set_logging_level",
+			f: func(client *Client, request protocol.ClientRequest) (protocol.ServerResponse, error) {
+				return client.SetLoggingLevel(context.Background(), request.(*protocol.SetLoggingLevelRequest).Level)
+			},
+			request:          protocol.NewSetLoggingLevelRequest(protocol.LogWarning),
+			expectedResponse: &protocol.SetLoggingLevelResult{},
+		},
+		{
+			name: "test_complete",
+			f: func(client *Client, request protocol.ClientRequest) (protocol.ServerResponse, error) {
+				return client.Complete(context.Background(), request.(*protocol.CompleteRequest))
+			},
+			request:          protocol.NewCompleteRequest("repository", "go", protocol.NewPromptReference("prompt1")),
+			expectedResponse: protocol.NewCompleteResult([]string{"go-mcp", "go-sdk"}, true, 10),
+		},
 	}
 
 	for _, tt := range tests {
@@ -215,101 +266,986 @@ func TestClientCall(t *testing.T) {
 	}
 }
 
-func testClientInit(t *testing.T, in io.ReadWriteCloser, out io.ReadWriter, outScan *bufio.Scanner) *Client {
-	req := protocol.InitializeRequest{
-		ClientInfo: protocol.Implementation{
-			Name:    "test_client",
-			Version: "0.1",
-		},
-		Capabilities:    protocol.ClientCapabilities{},
-		ProtocolVersion: protocol.Version,
-	}
-
-	ch := make(chan struct{})
+func TestClientCallWithProgress(t *testing.T) {
+	reader1, writer1 := io.Pipe()
+	reader2, writer2 := io.Pipe()
 
-	go func() {
-		var reqBytes []byte
-		if outScan.Scan() { // Read initialization request
-			reqBytes = outScan.Bytes()
-		}
-		if err := outScan.Err(); err != nil {
-			t.Errorf("outScan: %+v", err)
-			return
+	var (
+		in io.ReadWriteCloser = struct {
+			io.Reader
+			io.Writer
+			io.Closer
+		}{
+			Reader: reader1,
+			Writer: writer1,
+			Closer: reader1,
 		}
 
-		jsonrpcReq := &protocol.JSONRPCRequest{}
-		if err := pkg.JSONUnmarshal(reqBytes, &jsonrpcReq); err != nil {
-			t.Errorf("Json Unmarshal: %+v", err)
-			return
+		out io.ReadWriter = struct {
+			io.Reader
+			io.Writer
+		}{
+			Reader: reader2,
+			Writer: writer2,
 		}
 
-		request := make(map[string]interface{})
-		if err := pkg.JSONUnmarshal(jsonrpcReq.RawParams, &request); err != nil {
-			t.Errorf("Json Unmarshal: %+v", err)
-			return
-		}
+		outScan = bufio.NewScanner(out)
+	)
 
-		expectedReqBytes, err := json.Marshal(req)
-		if err != nil {
-			t.Errorf("json Marshal: %+v", err)
-			return
-		}
-		var expectedReqMap map[string]interface{}
-		if err = pkg.JSONUnmarshal(expectedReqBytes, &expectedReqMap); err != nil {
-			t.Errorf("json Unmarshal: %+v", err)
-			return
-		}
+	client := testClientInit(t, in, out, outScan)
 
-		if !reflect.DeepEqual(request, expectedReqMap) {
-			t.Errorf("response not as expected.\ngot  = %v\nwant = %v", request, expectedReqMap)
-			return
-		}
+	progressHandler := func(progressCh chan<- *protocol.ProgressNotification) CallOption {
+		return WithProgressHandler(func(_ context.Context, notify *protocol.ProgressNotification) {
+			if notify.Progress != 50 || notify.Total != 100 || notify.Message != "half way" {
+				t.Errorf("progress not as expected: %+v", notify)
+			}
+			progressCh <- notify
+		})
+	}
 
-		resp := &protocol.InitializeResult{
-			ServerInfo: protocol.Implementation{
-				Name:    "test_server",
-				Version: "0.1",
+	tests := []struct {
+		name             string
+		expectedResponse interface{}
+		call             func(opt CallOption) (interface{}, error)
+	}{
+		{
+			name:             "tools/call",
+			expectedResponse: protocol.NewCallToolResult([]protocol.Content{&protocol.TextContent{Type: "text", Text: "success"}}, false),
+			call: func(opt CallOption) (interface{}, error) {
+				return client.CallTool(context.Background(), protocol.NewCallToolRequest("test_tool", nil), opt)
 			},
-			Capabilities: protocol.ServerCapabilities{
-				Prompts: &protocol.PromptsCapability{
-					ListChanged: true,
-				},
-				Resources: &protocol.ResourcesCapability{
-					ListChanged: true,
-					Subscribe:   true,
-				},
-				Tools: &protocol.ToolsCapability{
-					ListChanged: true,
-				},
+		},
+		{
+			name:             "resources/read",
+			expectedResponse: protocol.NewReadResourceResult([]protocol.ResourceContents{protocol.TextResourceContents{URI: "file:///big.log", Text: "log"}}),
+			call: func(opt CallOption) (interface{}, error) {
+				return client.ReadResource(context.Background(), protocol.NewReadResourceRequest("file:///big.log"), opt)
 			},
-			ProtocolVersion: protocol.Version,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			progressCh := make(chan *protocol.ProgressNotification, 1)
+
+			go func() {
+				var reqBytes []byte
+				if outScan.Scan() {
+					reqBytes = outScan.Bytes()
+				}
+
+				jsonrpcReq := &protocol.JSONRPCRequest{}
+				if err := pkg.JSONUnmarshal(reqBytes, &jsonrpcReq); err != nil {
+					t.Errorf("Json Unmarshal: %+v", err)
+					return
+				}
+				var request struct {
+					Meta map[string]interface{} `json:"_meta"`
+				}
+				if err := pkg.JSONUnmarshal(jsonrpcReq.RawParams, &request); err != nil {
+					t.Errorf("Json Unmarshal: %+v", err)
+					return
+				}
+				token, ok := request.Meta[protocol.ProgressTokenMetaKey]
+				if !ok {
+					t.Errorf("request lack progress token: %s", reqBytes)
+					return
+				}
+
+				notifyBytes, err := json.Marshal(protocol.NewJSONRPCNotification(protocol.NotificationProgress,
+					protocol.NewProgressNotificationWithMessage(token, 50, 100, "half way")))
+				if err != nil {
+					t.Errorf("Json Marshal: %+v", err)
+					return
+				}
+				if _, err = in.Write(append(notifyBytes, "\n"...)); err != nil {
+					t.Errorf("in Write: %+v", err)
+					return
+				}
+				<-progressCh
+
+				respBytes, err := json.Marshal(protocol.NewJSONRPCSuccessResponse(jsonrpcReq.ID, tt.expectedResponse))
+				if err != nil {
+					t.Errorf("Json Marshal: %+v", err)
+					return
+				}
+				if _, err = in.Write(append(respBytes, "\n"...)); err != nil {
+					t.Errorf("in Write: %+v", err)
+					return
+				}
+			}()
+
+			response, err := tt.call(progressHandler(progressCh))
+			if err != nil {
+				t.Fatalf("call: %+v", err)
+			}
+			if !reflect.DeepEqual(response, tt.expectedResponse) {
+				t.Fatalf("response not as expected.\ngot  = %+v\nwant = %+v", response, tt.expectedResponse)
+			}
+		})
+	}
+}
+
+func TestClientCallCanceled(t *testing.T) {
+	reader1, writer1 := io.Pipe()
+	reader2, writer2 := io.Pipe()
+
+	var (
+		in io.ReadWriteCloser = struct {
+			io.Reader
+			io.Writer
+			io.Closer
+		}{
+			Reader: reader1,
+			Writer: writer1,
+			Closer: reader1,
 		}
 
-		respBytes, err := json.Marshal(protocol.NewJSONRPCSuccessResponse(jsonrpcReq.ID, resp))
-		if err != nil {
-			t.Errorf("Json Marshal: %+v", err)
-			return
+		out io.ReadWriter = struct {
+			io.Reader
+			io.Writer
+		}{
+			Reader: reader2,
+			Writer: writer2,
 		}
-		if _, err := in.Write(append(respBytes, "\n"...)); err != nil {
-			t.Errorf("in Write: %+v", err)
-			return
+
+		outScan = bufio.NewScanner(out)
+	)
+
+	client := testClientInit(t, in, out, outScan)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := client.CallTool(ctx, protocol.NewCallToolRequest("test_tool", nil))
+		errCh <- err
+	}()
+
+	// the server never answers the tools/call request
+	if !outScan.Scan() {
+		t.Fatalf("outScan: %+v", outScan.Err())
+	}
+	jsonrpcReq := &protocol.JSONRPCRequest{}
+	if err := pkg.JSONUnmarshal(outScan.Bytes(), &jsonrpcReq); err != nil {
+		t.Fatalf("Json Unmarshal: %+v", err)
+	}
+
+	if !outScan.Scan() {
+		t.Fatalf("outScan: %+v", outScan.Err())
+	}
+	notify := &protocol.JSONRPCNotification{}
+	if err := pkg.JSONUnmarshal(outScan.Bytes(), &notify); err != nil {
+		t.Fatalf("Json Unmarshal: %+v", err)
+	}
+	if notify.Method != protocol.NotificationCancelled {
+		t.Fatalf("notify method not as expected: %s", notify.Method)
+	}
+	cancelled := &protocol.CancelledNotification{}
+	if err := pkg.JSONUnmarshal(notify.RawParams, cancelled); err != nil {
+		t.Fatalf("Json Unmarshal: %+v", err)
+	}
+	if !reflect.DeepEqual(cancelled.RequestID, jsonrpcReq.ID) {
+		t.Fatalf("requestId not as expected.\ngot  = %v\nwant = %v", cancelled.RequestID, jsonrpcReq.ID)
+	}
+
+	if err := <-errCh; !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("CallTool error not as expected: %+v", err)
+	}
+}
+
+func TestClientListToolsAll(t *testing.T) {
+	reader1, writer1 := io.Pipe()
+	reader2, writer2 := io.Pipe()
+
+	var (
+		in io.ReadWriteCloser = struct {
+			io.Reader
+			io.Writer
+			io.Closer
+		}{
+			Reader: reader1,
+			Writer: writer1,
+			Closer: reader1,
 		}
 
-		if outScan.Scan() { // Read initialization notification
-			notifyBytes := outScan.Bytes()
-			fmt.Println("initialization notify: " + string(notifyBytes))
+		out io.ReadWriter = struct {
+			io.Reader
+			io.Writer
+		}{
+			Reader: reader2,
+			Writer: writer2,
 		}
-		if err := outScan.Err(); err != nil {
-			t.Errorf("outScan: %+v", err)
-			return
+
+		outScan = bufio.NewScanner(out)
+	)
+
+	client := testClientInit(t, in, out, outScan)
+
+	pages := []*protocol.ListToolsResult{
+		protocol.NewListToolsResult([]*protocol.Tool{{Name: "tool_a"}, {Name: "tool_b"}}, "cursor_1"),
+		protocol.NewListToolsResult([]*protocol.Tool{{Name: "tool_c"}}, ""),
+		protocol.NewListToolsResult([]*protocol.Tool{{Name: "tool_c"}}, ""),
+	}
+	expectedCursors := []string{"", "cursor_1", "cursor_1"}
+
+	go func() {
+		for i, page := range pages {
+			if !outScan.Scan() {
+				t.Errorf("outScan: %+v", outScan.Err())
+				return
+			}
+			jsonrpcReq := &protocol.JSONRPCRequest{}
+			if err := pkg.JSONUnmarshal(outScan.Bytes(), &jsonrpcReq); err != nil {
+				t.Errorf("Json Unmarshal: %+v", err)
+				return
+			}
+			request := &protocol.ListToolsRequest{}
+			if err := pkg.JSONUnmarshal(jsonrpcReq.RawParams, request); err != nil {
+				t.Errorf("Json Unmarshal: %+v", err)
+				return
+			}
+			if request.Cursor != expectedCursors[i] {
+				t.Errorf("cursor not as expected.\ngot  = %q\nwant = %q", request.Cursor, expectedCursors[i])
+				return
+			}
+
+			respBytes, err := json.Marshal(protocol.NewJSONRPCSuccessResponse(jsonrpcReq.ID, page))
+			if err != nil {
+				t.Errorf("Json Marshal: %+v", err)
+				return
+			}
+			if _, err := in.Write(append(respBytes, "\n"...)); err != nil {
+				t.Errorf("in Write: %+v", err)
+				return
+			}
 		}
-		ch <- struct{}{}
 	}()
 
-	client, err := NewClient(transport.NewMockClientTransport(in, out), WithClientInfo(req.ClientInfo))
+	tools, err := client.ListToolsAll(context.Background())
 	if err != nil {
-		t.Fatalf("NewServer: %+v", err)
+		t.Fatalf("ListToolsAll: %+v", err)
+	}
+
+	expectedTools := []*protocol.Tool{{Name: "tool_a"}, {Name: "tool_b"}, {Name: "tool_c"}}
+	if !reflect.DeepEqual(tools, expectedTools) {
+		t.Fatalf("tools not as expected.\ngot  = %+v\nwant = %+v", tools, expectedTools)
+	}
+
+	page, err := client.ListToolsPage(context.Background(), protocol.NewListToolsRequest().WithCursor("cursor_1"))
+	if err != nil {
+		t.Fatalf("ListToolsPage: %+v", err)
+	}
+	if !reflect.DeepEqual(page, pages[2]) {
+		t.Fatalf("page not as expected.\ngot  = %+v\nwant = %+v", page, pages[2])
+	}
+}
+
+func TestClientBatch(t *testing.T) {
+	reader1, writer1 := io.Pipe()
+	reader2, writer2 := io.Pipe()
+
+	var (
+		in io.ReadWriteCloser = struct {
+			io.Reader
+			io.Writer
+			io.Closer
+		}{
+			Reader: reader1,
+			Writer: writer1,
+			Closer: reader1,
+		}
+
+		out io.ReadWriter = struct {
+			io.Reader
+			io.Writer
+		}{
+			Reader: reader2,
+			Writer: writer2,
+		}
+
+		outScan = bufio.NewScanner(out)
+	)
+
+	provider := &testRootsProvider{roots: []protocol.Root{{Name: "workspace", URI: "file:///workspace"}}}
+	testClientInitWithCapabilities(t, in, out, outScan,
+		protocol.ClientCapabilities{Roots: &protocol.RootsCapability{ListChanged: true}, Sampling: struct{}{}},
+		WithRootsProvider(provider), WithSamplingHandler(&testSamplingHandler{delay: 50 * time.Millisecond}))
+
+	batchBytes, err := json.Marshal([]interface{}{
+		protocol.NewJSONRPCRequest("1", protocol.SamplingCreateMessage, protocol.NewCreateMessageRequest(
+			[]protocol.SamplingMessage{{Role: protocol.RoleUser, Content: &protocol.TextContent{Type: "text", Text: "hello"}}}, 100)),
+		protocol.NewJSONRPCNotification(protocol.NotificationToolsListChanged, protocol.NewToolListChangedNotification()),
+		protocol.NewJSONRPCRequest("2", protocol.RootsList, protocol.NewListRootsRequest()),
+		protocol.NewJSONRPCRequest("3", protocol.Ping, protocol.NewPingRequest()),
+	})
+	if err != nil {
+		t.Fatalf("json Marshal: %+v", err)
+	}
+	if _, err = in.Write(append(batchBytes, "\n"...)); err != nil {
+		t.Fatalf("in Write: %+v", err)
+	}
+
+	// the sampling request finishes last, but its response still comes first
+	if !outScan.Scan() {
+		t.Fatalf("outScan: %+v", outScan.Err())
+	}
+	var responses []*protocol.JSONRPCResponse
+	if err = pkg.JSONUnmarshal(outScan.Bytes(), &responses); err != nil {
+		t.Fatalf("Json Unmarshal: %+v", err)
+	}
+	var ids []interface{}
+	for _, resp := range responses {
+		if resp.Error != nil {
+			t.Fatalf("unexpected error response: %+v", resp.Error)
+		}
+		ids = append(ids, resp.ID)
+	}
+	if !reflect.DeepEqual(ids, []interface{}{"1", "2", "3"}) {
+		t.Fatalf("response ids not as expected: %v", ids)
+	}
+
+	sampling := &protocol.CreateMessageResult{}
+	if err = pkg.JSONUnmarshal(responses[0].RawResult, sampling); err != nil {
+		t.Fatalf("Json Unmarshal: %+v", err)
+	}
+	if text, ok := sampling.Content.(*protocol.TextContent); !ok || text.Text != "echo: hello" {
+		t.Fatalf("sampling result not as expected: %+v", sampling.Content)
+	}
+	roots := &protocol.ListRootsResult{}
+	if err = pkg.JSONUnmarshal(responses[1].RawResult, roots); err != nil {
+		t.Fatalf("Json Unmarshal: %+v", err)
+	}
+	if !reflect.DeepEqual(roots.Roots, provider.roots) {
+		t.Fatalf("roots not as expected.\ngot  = %+v\nwant = %+v", roots.Roots, provider.roots)
+	}
+}
+
+type testSamplingHandler struct {
+	delay time.Duration
+}
+
+func (h *testSamplingHandler) CreateMessage(_ context.Context, request *protocol.CreateMessageRequest) (*protocol.CreateMessageResult, error) {
+	time.Sleep(h.delay)
+	text := request.Messages[0].Content.(*protocol.TextContent).Text
+	return protocol.NewCreateMessageResult(&protocol.TextContent{Type: "text", Text: "echo: " + text}, protocol.RoleAssistant, "test_model", ""), nil
+}
+
+type testElicitationHandler struct{}
+
+func (h *testElicitationHandler) Elicit(_ context.Context, request *protocol.ElicitRequest) (*protocol.ElicitResult, error) {
+	if _, ok := request.RequestedSchema.Properties["confirm"]; !ok {
+		return protocol.NewElicitResult(protocol.ElicitDecline, nil), nil
+	}
+	return protocol.NewElicitResult(protocol.ElicitAccept, map[string]interface{}{"confirm": true}), nil
+}
+
+func TestClientElicit(t *testing.T) {
+	reader1, writer1 := io.Pipe()
+	reader2, writer2 := io.Pipe()
+
+	var (
+		in io.ReadWriteCloser = struct {
+			io.Reader
+			io.Writer
+			io.Closer
+		}{
+			Reader: reader1,
+			Writer: writer1,
+			Closer: reader1,
+		}
+
+		out io.ReadWriter = struct {
+			io.Reader
+			io.Writer
+		}{
+			Reader: reader2,
+			Writer: writer2,
+		}
+
+		outScan = bufio.NewScanner(out)
+	)
+
+	testClientInitWithCapabilities(t, in, out, outScan,
+		protocol.ClientCapabilities{Elicitation: struct{}{}}, WithElicitationHandler(&testElicitationHandler{}))
+
+	request, err := protocol.NewElicitRequest("confirm the deployment", struct {
+		Confirm bool `json:"confirm"`
+	}{})
+	if err != nil {
+		t.Fatalf("NewElicitRequest: %+v", err)
+	}
+	reqBytes, err := json.Marshal(protocol.NewJSONRPCRequest("1", protocol.ElicitationCreate, request))
+	if err != nil {
+		t.Fatalf("json Marshal: %+v", err)
+	}
+	if _, err = in.Write(append(reqBytes, "\n"...)); err != nil {
+		t.Fatalf("in Write: %+v", err)
+	}
+
+	if !outScan.Scan() {
+		t.Fatalf("outScan: %+v", outScan.Err())
+	}
+	resp := &protocol.JSONRPCResponse{}
+	if err = pkg.JSONUnmarshal(outScan.Bytes(), resp); err != nil {
+		t.Fatalf("Json Unmarshal: %+v", err)
+	}
+	result := &protocol.ElicitResult{}
+	if err = pkg.JSONUnmarshal(resp.RawResult, result); err != nil {
+		t.Fatalf("Json Unmarshal: %+v", err)
+	}
+	expectedResult := protocol.NewElicitResult(protocol.ElicitAccept, map[string]interface{}{"confirm": true})
+	if !reflect.DeepEqual(result, expectedResult) {
+		t.Fatalf("result not as expected.\ngot  = %+v\nwant = %+v", result, expectedResult)
+	}
+}
+
+type testRootsProvider struct {
+	roots []protocol.Root
+}
+
+func (p *testRootsProvider) ListRoots(context.Context, *protocol.ListRootsRequest) (*protocol.ListRootsResult, error) {
+	return protocol.NewListRootsResult(p.roots), nil
+}
+
+func TestClientRoots(t *testing.T) {
+	reader1, writer1 := io.Pipe()
+	reader2, writer2 := io.Pipe()
+
+	var (
+		in io.ReadWriteCloser = struct {
+			io.Reader
+			io.Writer
+			io.Closer
+		}{
+			Reader: reader1,
+			Writer: writer1,
+			Closer: reader1,
+		}
+
+		out io.ReadWriter = struct {
+			io.Reader
+			io.Writer
+		}{
+			Reader: reader2,
+			Writer: writer2,
+		}
+
+		outScan = bufio.NewScanner(out)
+	)
+
+	provider := &testRootsProvider{roots: []protocol.Root{{Name: "workspace", URI: "file:///workspace"}}}
+	client := testClientInitWithCapabilities(t, in, out, outScan,
+		protocol.ClientCapabilities{Roots: &protocol.RootsCapability{ListChanged: true}}, WithRootsProvider(provider))
+
+	reqBytes, err := json.Marshal(protocol.NewJSONRPCRequest("1", protocol.RootsList, protocol.NewListRootsRequest()))
+	if err != nil {
+		t.Fatalf("json Marshal: %+v", err)
+	}
+	if _, err = in.Write(append(reqBytes, "\n"...)); err != nil {
+		t.Fatalf("in Write: %+v", err)
+	}
+
+	if !outScan.Scan() {
+		t.Fatalf("outScan: %+v", outScan.Err())
+	}
+	resp := &protocol.JSONRPCResponse{}
+	if err = pkg.JSONUnmarshal(outScan.Bytes(), resp); err != nil {
+		t.Fatalf("Json Unmarshal: %+v", err)
+	}
+	result := &protocol.ListRootsResult{}
+	if err = pkg.JSONUnmarshal(resp.RawResult, result); err != nil {
+		t.Fatalf("Json Unmarshal: %+v", err)
+	}
+	if !reflect.DeepEqual(result.Roots, provider.roots) {
+		t.Fatalf("roots not as expected.\ngot  = %+v\nwant = %+v", result.Roots, provider.roots)
+	}
+
+	go func() {
+		if err := client.NotifyRootsChanged(context.Background()); err != nil {
+			t.Errorf("NotifyRootsChanged: %+v", err)
+		}
+	}()
+	if !outScan.Scan() {
+		t.Fatalf("outScan: %+v", outScan.Err())
+	}
+	notify := &protocol.JSONRPCNotification{}
+	if err = pkg.JSONUnmarshal(outScan.Bytes(), notify); err != nil {
+		t.Fatalf("Json Unmarshal: %+v", err)
+	}
+	if notify.Method != protocol.NotificationRootsListChanged {
+		t.Fatalf("notify method not as expected: %s", notify.Method)
+	}
+}
+
+type testLogHandler struct {
+	ch chan *protocol.LogMessageNotification
+}
+
+func (h *testLogHandler) LogMessage(_ context.Context, notify *protocol.LogMessageNotification) error {
+	h.ch <- notify
+	return nil
+}
+
+func TestClientLogMessage(t *testing.T) {
+	reader1, writer1 := io.Pipe()
+	reader2, writer2 := io.Pipe()
+
+	var (
+		in io.ReadWriteCloser = struct {
+			io.Reader
+			io.Writer
+			io.Closer
+		}{
+			Reader: reader1,
+			Writer: writer1,
+			Closer: reader1,
+		}
+
+		out io.ReadWriter = struct {
+			io.Reader
+			io.Writer
+		}{
+			Reader: reader2,
+			Writer: writer2,
+		}
+
+		outScan = bufio.NewScanner(out)
+	)
+
+	handler := &testLogHandler{ch: make(chan *protocol.LogMessageNotification, 1)}
+	testClientInit(t, in, out, outScan, WithLogHandler(handler))
+
+	expectedNotify := protocol.NewLogMessageNotificationWithData(protocol.LogError, "test_logger", map[string]interface{}{"error": "boom"})
+	notifyBytes, err := json.Marshal(protocol.NewJSONRPCNotification(protocol.NotificationLogMessage, expectedNotify))
+	if err != nil {
+		t.Fatalf("json Marshal: %+v", err)
+	}
+	if _, err = in.Write(append(notifyBytes, "\n"...)); err != nil {
+		t.Fatalf("in Write: %+v", err)
+	}
+
+	notify := <-handler.ch
+	if !reflect.DeepEqual(notify, expectedNotify) {
+		t.Fatalf("notify not as expected.\ngot  = %+v\nwant = %+v", notify, expectedNotify)
+	}
+}
+
+func testClientInit(t *testing.T, in io.ReadWriteCloser, out io.ReadWriter, outScan *bufio.Scanner, opts ...Option) *Client {
+	return testClientInitWithCapabilities(t, in, out, outScan, protocol.ClientCapabilities{}, opts...)
+}
+
+func testClientInitWithCapabilities(t *testing.T, in io.ReadWriteCloser, out io.ReadWriter, outScan *bufio.Scanner,
+	capabilities protocol.ClientCapabilities, opts ...Option,
+) *Client { //nolint:whitespace
+	req := protocol.InitializeRequest{
+		ClientInfo: protocol.Implementation{
+			Name:    "test_client",
+			Version: "0.1",
+		},
+		Capabilities:    capabilities,
+		ProtocolVersion: protocol.Version,
+	}
+
+	ch := make(chan struct{})
+
+	go func() {
+		var reqBytes []byte
+		if outScan.Scan() { // Read initialization request
+			reqBytes = outScan.Bytes()
+		}
+		if err := outScan.Err(); err != nil {
+			t.Errorf("outScan: %+v", err)
+			return
+		}
+
+		jsonrpcReq := &protocol.JSONRPCRequest{}
+		if err := pkg.JSONUnmarshal(reqBytes, &jsonrpcReq); err != nil {
+			t.Errorf("Json Unmarshal: %+v", err)
+			return
+		}
+
+		request := make(map[string]interface{})
+		if err := pkg.JSONUnmarshal(jsonrpcReq.RawParams, &request); err != nil {
+			t.Errorf("Json Unmarshal: %+v", err)
+			return
+		}
+
+		expectedReqBytes, err := json.Marshal(req)
+		if err != nil {
+			t.Errorf("json Marshal: %+v", err)
+			return
+		}
+		var expectedReqMap map[string]interface{}
+		if err = pkg.JSONUnmarshal(expectedReqBytes, &expectedReqMap); err != nil {
+			t.Errorf("json Unmarshal: %+v", err)
+			return
+		}
+
+		if !reflect.DeepEqual(request, expectedReqMap) {
+			t.Errorf("response not as expected.\ngot  = %v\nwant = %v", request, expectedReqMap)
+			return
+		}
+
+		resp := &protocol.InitializeResult{
+			ServerInfo: protocol.Implementation{
+				Name:    "test_server",
+				Version: "0.1",
+			},
+			Capabilities: protocol.ServerCapabilities{
+				Logging:     struct{}{},
+				Completions: struct{}{},
+				Prompts: &protocol.PromptsCapability{
+					ListChanged: true,
+				},
+				Resources: &protocol.ResourcesCapability{
+					ListChanged: true,
+					Subscribe:   true,
+				},
+				Tools: &protocol.ToolsCapability{
+					ListChanged: true,
+				},
+			},
+			ProtocolVersion: protocol.Version,
+		}
+
+		respBytes, err := json.Marshal(protocol.NewJSONRPCSuccessResponse(jsonrpcReq.ID, resp))
+		if err != nil {
+			t.Errorf("Json Marshal: %+v", err)
+			return
+		}
+		if _, err := in.Write(append(respBytes, "\n"...)); err != nil {
+			t.Errorf("in Write: %+v", err)
+			return
+		}
+
+		if outScan.Scan() { // Read initialization notification
+			notifyBytes := outScan.Bytes()
+			fmt.Println("initialization notify: " + string(notifyBytes))
+		}
+		if err := outScan.Err(); err != nil {
+			t.Errorf("outScan: %+v", err)
+			return
+		}
+		ch <- struct{}{}
+	}()
+
+	client, err := NewClient(transport.NewMockClientTransport(in, out), append([]Option{WithClientInfo(req.ClientInfo)}, opts...)...)
+	if err != nil {
+		t.Fatalf("NewServer: %+v", err)
+	}
+	<-ch
+	return client
+}
+
+func TestClientInterceptor(t *testing.T) {
+	reader1, writer1 := io.Pipe()
+	reader2, writer2 := io.Pipe()
+
+	var (
+		in io.ReadWriteCloser = struct {
+			io.Reader
+			io.Writer
+			io.Closer
+		}{
+			Reader: reader1,
+			Writer: writer1,
+			Closer: reader1,
+		}
+
+		out io.ReadWriter = struct {
+			io.Reader
+			io.Writer
+		}{
+			Reader: reader2,
+			Writer: writer2,
+		}
+
+		outScan = bufio.NewScanner(out)
+	)
+
+	var called, sent, received []protocol.Method
+	record := func(methods *[]protocol.Method) Interceptor {
+		return func(ctx context.Context, method protocol.Method, params protocol.ClientRequest, invoker Invoker) (json.RawMessage, error) {
+			*methods = append(*methods, method)
+			return invoker(ctx, method, params)
+		}
+	}
+	cache := make(map[protocol.Method]json.RawMessage)
+	caching := func(ctx context.Context, method protocol.Method, params protocol.ClientRequest, invoker Invoker) (json.RawMessage, error) {
+		if result, ok := cache[method]; ok {
+			return result, nil
+		}
+		result, err := invoker(ctx, method, params)
+		if err == nil && method == protocol.ToolsList {
+			cache[method] = result
+		}
+		return result, err
+	}
+	serverRequestInterceptor := func(ctx context.Context, method protocol.Method, rawParams json.RawMessage, handler RequestHandler) (protocol.ClientResponse, error) {
+		received = append(received, method)
+		return handler(ctx, method, rawParams)
+	}
+
+	client := testClientInit(t, in, out, outScan,
+		WithInterceptor(record(&called), caching, record(&sent)), WithServerRequestInterceptor(serverRequestInterceptor))
+
+	expectedResult := protocol.NewListToolsResult([]*protocol.Tool{{Name: "tool_a"}}, "")
+	go func() {
+		if !outScan.Scan() {
+			t.Errorf("outScan: %+v", outScan.Err())
+			return
+		}
+		jsonrpcReq := &protocol.JSONRPCRequest{}
+		if err := pkg.JSONUnmarshal(outScan.Bytes(), &jsonrpcReq); err != nil {
+			t.Errorf("Json Unmarshal: %+v", err)
+			return
+		}
+		respBytes, err := json.Marshal(protocol.NewJSONRPCSuccessResponse(jsonrpcReq.ID, expectedResult))
+		if err != nil {
+			t.Errorf("Json Marshal: %+v", err)
+			return
+		}
+		if _, err := in.Write(append(respBytes, "\n"...)); err != nil {
+			t.Errorf("in Write: %+v", err)
+			return
+		}
+	}()
+
+	for i := 0; i < 2; i++ {
+		result, err := client.ListTools(context.Background())
+		if err != nil {
+			t.Fatalf("ListTools: %+v", err)
+		}
+		if !reflect.DeepEqual(result, expectedResult) {
+			t.Fatalf("result not as expected.\ngot  = %+v\nwant = %+v", result, expectedResult)
+		}
+	}
+
+	if expected := []protocol.Method{protocol.Initialize, protocol.ToolsList, protocol.ToolsList}; !reflect.DeepEqual(called, expected) {
+		t.Fatalf("called methods not as expected.\ngot  = %v\nwant = %v", called, expected)
+	}
+	if expected := []protocol.Method{protocol.Initialize, protocol.ToolsList}; !reflect.DeepEqual(sent, expected) {
+		t.Fatalf("sent methods not as expected.\ngot  = %v\nwant = %v", sent, expected)
+	}
+
+	reqBytes, err := json.Marshal(protocol.NewJSONRPCRequest("1", protocol.Ping, protocol.NewPingRequest()))
+	if err != nil {
+		t.Fatalf("json Marshal: %+v", err)
+	}
+	if _, err = in.Write(append(reqBytes, "\n"...)); err != nil {
+		t.Fatalf("in Write: %+v", err)
+	}
+	if !outScan.Scan() {
+		t.Fatalf("outScan: %+v", outScan.Err())
+	}
+	if expected := []protocol.Method{protocol.Ping}; !reflect.DeepEqual(received, expected) {
+		t.Fatalf("received methods not as expected.\ngot  = %v\nwant = %v", received, expected)
+	}
+}
+
+func TestClientCustomMethod(t *testing.T) {
+	reader1, writer1 := io.Pipe()
+	reader2, writer2 := io.Pipe()
+
+	var (
+		in io.ReadWriteCloser = struct {
+			io.Reader
+			io.Writer
+			io.Closer
+		}{
+			Reader: reader1,
+			Writer: writer1,
+			Closer: reader1,
+		}
+
+		out io.ReadWriter = struct {
+			io.Reader
+			io.Writer
+		}{
+			Reader: reader2,
+			Writer: writer2,
+		}
+
+		outScan = bufio.NewScanner(out)
+	)
+
+	client := testClientInitWithCapabilities(t, in, out, outScan,
+		protocol.ClientCapabilities{Experimental: map[string]interface{}{"acme/search": map[string]interface{}{"version": "1"}}},
+		WithExperimentalCapability("acme/search", map[string]interface{}{"version": "1"}))
+
+	type searchResult struct {
+		Hits []string `json:"hits"`
+	}
+	go func() {
+		if !outScan.Scan() {
+			t.Errorf("outScan: %+v", outScan.Err())
+			return
+		}
+		jsonrpcReq := &protocol.JSONRPCRequest{}
+		if err := pkg.JSONUnmarshal(outScan.Bytes(), &jsonrpcReq); err != nil {
+			t.Errorf("Json Unmarshal: %+v", err)
+			return
+		}
+		if jsonrpcReq.Method != "acme/search" || string(jsonrpcReq.RawParams) != `{"query":"go"}` {
+			t.Errorf("request not as expected: %s %s", jsonrpcReq.Method, jsonrpcReq.RawParams)
+			return
+		}
+		respBytes, err := json.Marshal(protocol.NewJSONRPCSuccessResponse(jsonrpcReq.ID, searchResult{Hits: []string{"go-mcp"}}))
+		if err != nil {
+			t.Errorf("Json Marshal: %+v", err)
+			return
+		}
+		if _, err := in.Write(append(respBytes, "\n"...)); err != nil {
+			t.Errorf("in Write: %+v", err)
+			return
+		}
+	}()
+
+	var result searchResult
+	if err := client.Call(context.Background(), "acme/search", map[string]string{"query": "go"}, &result); err != nil {
+		t.Fatalf("Call: %+v", err)
+	}
+	if !reflect.DeepEqual(result.Hits, []string{"go-mcp"}) {
+		t.Fatalf("result not as expected: %+v", result)
+	}
+
+	client.HandleMethod("acme/status", func(_ context.Context, rawParams json.RawMessage) (interface{}, error) {
+		var params map[string]string
+		if err := pkg.JSONUnmarshal(rawParams, &params); err != nil {
+			return nil, err
+		}
+		return map[string]string{"echo": params["name"]}, nil
+	})
+	client.HandleMethod("acme/reindex", func(context.Context, json.RawMessage) (interface{}, error) {
+		return nil, fmt.Errorf("%w: path is required", pkg.ErrInvalidParams)
+	})
+	notified := make(chan string, 1)
+	client.HandleNotification("acme/indexed", func(_ context.Context, rawParams json.RawMessage) error {
+		notified <- string(rawParams)
+		return nil
+	})
+	client.HandleMethod(string(protocol.RootsList), func(context.Context, json.RawMessage) (interface{}, error) { return nil, nil })
+	client.HandleNotification(string(protocol.NotificationToolsListChanged), func(context.Context, json.RawMessage) error { return nil })
+	if _, ok := client.methodHandlers.Load(string(protocol.RootsList)); ok {
+		t.Fatalf("method defined by MCP should not be registered")
+	}
+	if _, ok := client.notificationHandlers.Load(string(protocol.NotificationToolsListChanged)); ok {
+		t.Fatalf("notification defined by MCP should not be registered")
+	}
+
+	tests := []struct {
+		method   protocol.Method
+		expected string
+	}{
+		{method: "acme/status", expected: `{"jsonrpc":"2.0","id":"1","result":{"echo":"go"}}`},
+		{method: "acme/reindex", expected: fmt.Sprintf(`{"jsonrpc":"2.0","id":"1","error":{"code":%d,"message":"invalid params: path is required"}}`, protocol.InvalidParams)},
+		{method: "acme/unknown", expected: fmt.Sprintf(`{"jsonrpc":"2.0","id":"1","error":{"code":%d,"message":"method not support: method=acme/unknown"}}`, protocol.MethodNotFound)},
+	}
+	for _, tt := range tests {
+		reqBytes, err := json.Marshal(protocol.NewJSONRPCRequest("1", tt.method, map[string]string{"name": "go"}))
+		if err != nil {
+			t.Fatalf("json Marshal: %+v", err)
+		}
+		if _, err = in.Write(append(reqBytes, "\n"...)); err != nil {
+			t.Fatalf("in Write: %+v", err)
+		}
+		if !outScan.Scan() {
+			t.Fatalf("outScan: %+v", outScan.Err())
+		}
+		if got := outScan.Text(); got != tt.expected {
+			t.Fatalf("response not as expected.\ngot  = %s\nwant = %s", got, tt.expected)
+		}
+	}
+
+	notifyBytes, err := json.Marshal(protocol.NewJSONRPCNotification("acme/indexed", map[string]int{"files": 3}))
+	if err != nil {
+		t.Fatalf("json Marshal: %+v", err)
+	}
+	if _, err = in.Write(append(notifyBytes, "\n"...)); err != nil {
+		t.Fatalf("in Write: %+v", err)
+	}
+	select {
+	case params := <-notified:
+		if params != `{"files":3}` {
+			t.Fatalf("notification params not as expected: %s", params)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("notification handler not called")
+	}
+}
+
+func TestClientInterceptorWithMeta(t *testing.T) {
+	reader1, writer1 := io.Pipe()
+	reader2, writer2 := io.Pipe()
+
+	var (
+		in io.ReadWriteCloser = struct {
+			io.Reader
+			io.Writer
+			io.Closer
+		}{
+			Reader: reader1,
+			Writer: writer1,
+			Closer: reader1,
+		}
+
+		out io.ReadWriter = struct {
+			io.Reader
+			io.Writer
+		}{
+			Reader: reader2,
+			Writer: writer2,
+		}
+
+		outScan = bufio.NewScanner(out)
+	)
+
+	var params []protocol.ClientRequest
+	record := func(ctx context.Context, method protocol.Method, p protocol.ClientRequest, invoker Invoker) (json.RawMessage, error) {
+		if method == protocol.ToolsCall {
+			params = append(params, p)
+		}
+		return invoker(ctx, method, p)
+	}
+	client := testClientInit(t, in, out, outScan, WithInterceptor(record))
+
+	request := protocol.NewCallToolRequest("tool1", map[string]interface{}{"a": "b"})
+	go func() {
+		for i := 0; i < 2; i++ {
+			if !outScan.Scan() {
+				t.Errorf("outScan: %+v", outScan.Err())
+				return
+			}
+			jsonrpcReq := &protocol.JSONRPCRequest{}
+			if err := pkg.JSONUnmarshal(outScan.Bytes(), &jsonrpcReq); err != nil {
+				t.Errorf("Json Unmarshal: %+v", err)
+				return
+			}
+			if i == 1 && string(jsonrpcReq.RawParams) != `{"_meta":{"tenant":"t1"},"arguments":{"a":"b"},"name":"tool1"}` {
+				t.Errorf("params not as expected: %s", jsonrpcReq.RawParams)
+			}
+			respBytes, err := json.Marshal(protocol.NewJSONRPCSuccessResponse(jsonrpcReq.ID, protocol.NewCallToolResult(nil, false)))
+			if err != nil {
+				t.Errorf("Json Marshal: %+v", err)
+				return
+			}
+			if _, err := in.Write(append(respBytes, "\n"...)); err != nil {
+				t.Errorf("in Write: %+v", err)
+				return
+			}
+		}
+	}()
+
+	if _, err := client.CallTool(context.Background(), request); err != nil {
+		t.Fatalf("CallTool: %+v", err)
+	}
+	if _, err := client.CallTool(context.Background(), request, WithMeta(map[string]interface{}{"tenant": "t1"})); err != nil {
+		t.Fatalf("CallTool: %+v", err)
+	}
+	for _, p := range params {
+		if p != request {
+			t.Fatalf("interceptor should see the typed request, got %T", p)
+		}
 	}
-	<-ch
-	return client
 }
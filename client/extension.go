@@ -0,0 +1,66 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ThinkInAIXYZ/go-mcp/pkg"
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+)
+
+// MethodHandlerFunc handles a server request whose method isn't part of MCP, its result is sent back as the response result
+type MethodHandlerFunc func(ctx context.Context, rawParams json.RawMessage) (interface{}, error)
+
+// NotificationHandlerFunc handles a server notification whose method isn't part of MCP
+type NotificationHandlerFunc func(ctx context.Context, rawParams json.RawMessage) error
+
+// WithExperimentalCapability advertises a non-standard capability under capabilities.experimental during initialization
+func WithExperimentalCapability(name string, settings interface{}) Option {
+	return func(s *Client) {
+		if s.clientCapabilities.Experimental == nil {
+			s.clientCapabilities.Experimental = make(map[string]interface{})
+		}
+		s.clientCapabilities.Experimental[name] = settings
+	}
+}
+
+// HandleMethod registers the handler of a custom server request method, such as a vendor extension.
+// Requests go through the server request interceptors like any other. Methods defined by MCP are always handled
+// by the client itself, registering one of them is logged as an error and ignored.
+func (client *Client) HandleMethod(method string, handler MethodHandlerFunc) {
+	if protocol.Method(method).IsDefined() {
+		client.logger.Errorf("register method %s fail: method is defined by MCP", method)
+		return
+	}
+	client.methodHandlers.Store(method, handler)
+}
+
+// HandleNotification registers the handler of a custom server notification method.
+// Unlike requests, notifications don't go through the server request interceptors and the handler's ctx isn't tied to any request,
+// any _meta sent with the notification is left in rawParams.
+// Methods defined by MCP are always handled by the client itself, registering one of them is logged as an error and ignored.
+func (client *Client) HandleNotification(method string, handler NotificationHandlerFunc) {
+	if protocol.Method(method).IsDefined() {
+		client.logger.Errorf("register notification %s fail: method is defined by MCP", method)
+		return
+	}
+	client.notificationHandlers.Store(method, handler)
+}
+
+// Call sends a request with any method to the server and decodes the response result into result, which may be nil to discard it.
+// It is meant for methods the client has no dedicated call for, such as vendor extensions.
+func (client *Client) Call(ctx context.Context, method string, params interface{}, result interface{}, opts ...CallOption) error {
+	response, err := client.callServer(ctx, protocol.Method(method), params, opts...)
+	if err != nil {
+		return err
+	}
+
+	if result == nil {
+		return nil
+	}
+	if err = pkg.JSONUnmarshal(response, result); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package client
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 
 	"github.com/ThinkInAIXYZ/go-mcp/pkg"
 	"github.com/ThinkInAIXYZ/go-mcp/protocol"
@@ -25,6 +26,34 @@ func (client *Client) handleRequestWithCreateMessagesSampling(ctx context.Contex
 	return client.samplingHandler.CreateMessage(ctx, request)
 }
 
+func (client *Client) handleRequestWithElicit(ctx context.Context, rawParams json.RawMessage) (*protocol.ElicitResult, error) {
+	if client.clientCapabilities.Elicitation == nil {
+		return nil, pkg.ErrClientNotSupport
+	}
+
+	var request *protocol.ElicitRequest
+	if err := pkg.JSONUnmarshal(rawParams, &request); err != nil {
+		return nil, err
+	}
+
+	return client.elicitationHandler.Elicit(ctx, request)
+}
+
+func (client *Client) handleRequestWithListRoots(ctx context.Context, rawParams json.RawMessage) (*protocol.ListRootsResult, error) {
+	if client.clientCapabilities.Roots == nil {
+		return nil, pkg.ErrClientNotSupport
+	}
+
+	request := &protocol.ListRootsRequest{}
+	if len(rawParams) > 0 {
+		if err := pkg.JSONUnmarshal(rawParams, request); err != nil {
+			return nil, err
+		}
+	}
+
+	return client.rootsProvider.ListRoots(ctx, request)
+}
+
 func (client *Client) handleNotifyWithToolsListChanged(ctx context.Context, rawParams json.RawMessage) error {
 	notify := &protocol.ToolListChangedNotification{}
 	if len(rawParams) > 0 {
@@ -64,3 +93,27 @@ func (client *Client) handleNotifyWithResourcesUpdated(ctx context.Context, rawP
 	}
 	return client.notifyHandler.ResourcesUpdated(ctx, notify)
 }
+
+func (client *Client) handleNotifyWithProgress(ctx context.Context, rawParams json.RawMessage) error {
+	notify := &protocol.ProgressNotification{}
+	if err := pkg.JSONUnmarshal(rawParams, notify); err != nil {
+		return err
+	}
+
+	handler, ok := client.progressHandlers.Get(fmt.Sprint(notify.ProgressToken))
+	if !ok {
+		// the request may have already completed
+		client.logger.Debugf("receive progress for unknown token: %v", notify.ProgressToken)
+		return nil
+	}
+	handler(ctx, notify)
+	return nil
+}
+
+func (client *Client) handleNotifyWithLogMessage(ctx context.Context, rawParams json.RawMessage) error {
+	notify := &protocol.LogMessageNotification{}
+	if err := pkg.JSONUnmarshal(rawParams, notify); err != nil {
+		return err
+	}
+	return client.logHandler.LogMessage(ctx, notify)
+}
@@ -0,0 +1,57 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+)
+
+// Invoker sends a request to the server and returns its raw result
+type Invoker func(ctx context.Context, method protocol.Method, params protocol.ClientRequest) (json.RawMessage, error)
+
+// Interceptor wraps every request sent to the server. It may change params before calling invoker,
+// inspect the result and error afterwards, or skip invoker altogether and return a result of its own, e.g. a cached one.
+type Interceptor func(ctx context.Context, method protocol.Method, params protocol.ClientRequest, invoker Invoker) (json.RawMessage, error)
+
+// RequestHandler handles a request received from the server, such as sampling/createMessage
+type RequestHandler func(ctx context.Context, method protocol.Method, rawParams json.RawMessage) (protocol.ClientResponse, error)
+
+// ServerRequestInterceptor wraps the handling of every request received from the server,
+// it may call handler or answer the request by itself.
+type ServerRequestInterceptor func(ctx context.Context, method protocol.Method, rawParams json.RawMessage, handler RequestHandler) (protocol.ClientResponse, error)
+
+// WithInterceptor adds interceptors around every request sent to the server, the first interceptor added is the outermost one.
+func WithInterceptor(interceptors ...Interceptor) Option {
+	return func(s *Client) {
+		s.interceptors = append(s.interceptors, interceptors...)
+	}
+}
+
+// WithServerRequestInterceptor adds interceptors around every request received from the server,
+// the first interceptor added is the outermost one.
+func WithServerRequestInterceptor(interceptors ...ServerRequestInterceptor) Option {
+	return func(s *Client) {
+		s.serverRequestInterceptors = append(s.serverRequestInterceptors, interceptors...)
+	}
+}
+
+func chainInterceptors(invoker Invoker, interceptors []Interceptor) Invoker {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, next := interceptors[i], invoker
+		invoker = func(ctx context.Context, method protocol.Method, params protocol.ClientRequest) (json.RawMessage, error) {
+			return interceptor(ctx, method, params, next)
+		}
+	}
+	return invoker
+}
+
+func chainServerRequestInterceptors(handler RequestHandler, interceptors []ServerRequestInterceptor) RequestHandler {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, next := interceptors[i], handler
+		handler = func(ctx context.Context, method protocol.Method, rawParams json.RawMessage) (protocol.ClientResponse, error) {
+			return interceptor(ctx, method, rawParams, next)
+		}
+	}
+	return handler
+}
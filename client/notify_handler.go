@@ -12,6 +12,11 @@ type SamplingHandler interface {
 	CreateMessage(ctx context.Context, request *protocol.CreateMessageRequest) (*protocol.CreateMessageResult, error)
 }
 
+// ElicitationHandler asks the user for the input the server requests through elicitation/create.
+type ElicitationHandler interface {
+	Elicit(ctx context.Context, request *protocol.ElicitRequest) (*protocol.ElicitResult, error)
+}
+
 // NotifyHandler
 // When implementing a custom NotifyHandler, you can combine it with BaseNotifyHandler to implement it on demand without implementing extra methods.
 type NotifyHandler interface {
@@ -21,6 +26,52 @@ type NotifyHandler interface {
 	ResourcesUpdated(ctx context.Context, request *protocol.ResourceUpdatedNotification) error
 }
 
+// RootsProvider answers the server's roots/list requests with the roots the client exposes.
+type RootsProvider interface {
+	ListRoots(ctx context.Context, request *protocol.ListRootsRequest) (*protocol.ListRootsResult, error)
+}
+
+// ProgressHandler receives the progress notifications of a single request.
+type ProgressHandler func(ctx context.Context, notify *protocol.ProgressNotification)
+
+// LogHandler receives the log messages the server sends with notifications/message.
+type LogHandler interface {
+	LogMessage(ctx context.Context, notify *protocol.LogMessageNotification) error
+}
+
+// LoggerLogHandler forwards server log messages into a pkg.Logger, mapping the protocol level to the closest logger method.
+type LoggerLogHandler struct {
+	Logger pkg.Logger
+}
+
+func NewLoggerLogHandler(logger pkg.Logger) *LoggerLogHandler {
+	return &LoggerLogHandler{Logger: logger}
+}
+
+func (handler *LoggerLogHandler) LogMessage(_ context.Context, notify *protocol.LogMessageNotification) error {
+	data, ok := notify.Data.(string)
+	if !ok {
+		b, err := json.Marshal(notify.Data)
+		if err != nil {
+			return err
+		}
+		data = string(b)
+	}
+
+	format := "receive server log: level=%s, logger=%s, data=%s"
+	switch notify.Level {
+	case protocol.LogDebug:
+		handler.Logger.Debugf(format, notify.Level, notify.Logger, data)
+	case protocol.LogInfo, protocol.LogNotice:
+		handler.Logger.Infof(format, notify.Level, notify.Logger, data)
+	case protocol.LogWarning:
+		handler.Logger.Warnf(format, notify.Level, notify.Logger, data)
+	default:
+		handler.Logger.Errorf(format, notify.Level, notify.Logger, data)
+	}
+	return nil
+}
+
 type BaseNotifyHandler struct {
 	Logger pkg.Logger
 }
@@ -2,6 +2,7 @@ package client
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 
@@ -11,9 +12,84 @@ import (
 	"github.com/ThinkInAIXYZ/go-mcp/protocol"
 )
 
-func (client *Client) receive(_ context.Context, msg []byte) error {
+func (client *Client) receive(ctx context.Context, msg []byte) error {
 	defer pkg.Recover()
 
+	if gjson.ParseBytes(msg).IsArray() {
+		return client.receiveBatch(ctx, msg)
+	}
+	return client.receiveMessage(msg)
+}
+
+// receiveBatch handles a JSON-RPC batch: its notifications and responses are handled asynchronously as single messages would be,
+// its requests are handled concurrently and their responses are sent back as one array in the order of the requests.
+func (client *Client) receiveBatch(ctx context.Context, msg []byte) error {
+	var batch []json.RawMessage
+	if err := pkg.JSONUnmarshal(msg, &batch); err != nil {
+		return err
+	}
+	if len(batch) == 0 {
+		return fmt.Errorf("%w: empty batch", pkg.ErrRequestInvalid)
+	}
+
+	var (
+		errList     []error
+		responseChs []<-chan *protocol.JSONRPCResponse
+	)
+	for _, item := range batch {
+		if !gjson.GetBytes(item, "id").Exists() || !gjson.GetBytes(item, "method").Exists() {
+			if err := client.receiveMessage(item); err != nil {
+				errList = append(errList, err)
+			}
+			continue
+		}
+
+		responseCh := make(chan *protocol.JSONRPCResponse, 1)
+		responseChs = append(responseChs, responseCh)
+
+		req := &protocol.JSONRPCRequest{}
+		if err := pkg.JSONUnmarshal(item, &req); err != nil {
+			// a malformed request is answered inside the batch instead of failing the whole batch
+			responseCh <- protocol.NewJSONRPCErrorResponse(gjson.GetBytes(item, "id").Value(), errorCode(err), err.Error())
+			continue
+		}
+		if !req.IsValid() {
+			responseCh <- protocol.NewJSONRPCErrorResponse(req.ID, protocol.InvalidRequest, pkg.ErrRequestInvalid.Error())
+			continue
+		}
+		go func() {
+			defer pkg.Recover()
+			defer close(responseCh)
+
+			responseCh <- client.handleJSONRPCRequest(context.Background(), req)
+		}()
+	}
+
+	if len(responseChs) > 0 {
+		go func() {
+			defer pkg.Recover()
+
+			responses := make([]*protocol.JSONRPCResponse, 0, len(responseChs))
+			for _, responseCh := range responseChs {
+				if response := <-responseCh; response != nil {
+					responses = append(responses, response)
+				}
+			}
+
+			message, err := json.Marshal(responses)
+			if err != nil {
+				client.logger.Errorf("receive json marshal batch response error: %s", err.Error())
+				return
+			}
+			if err = client.transport.Send(ctx, message); err != nil {
+				client.logger.Errorf("send batch response error: %s", err.Error())
+			}
+		}()
+	}
+	return pkg.JoinErrors(errList)
+}
+
+func (client *Client) receiveMessage(msg []byte) error {
 	if !gjson.GetBytes(msg, "id").Exists() {
 		notify := &protocol.JSONRPCNotification{}
 		if err := pkg.JSONUnmarshal(msg, &notify); err != nil {
@@ -69,35 +145,60 @@ func (client *Client) receive(_ context.Context, msg []byte) error {
 }
 
 func (client *Client) receiveRequest(ctx context.Context, request *protocol.JSONRPCRequest) error {
-	var (
-		result protocol.ClientResponse
-		err    error
-	)
+	message, err := json.Marshal(client.handleJSONRPCRequest(ctx, request))
+	if err != nil {
+		return err
+	}
 
-	switch request.Method {
-	case protocol.Ping:
-		result, err = client.handleRequestWithPing()
-	// case protocol.RootsList:
-	// 	result, err = client.handleRequestWithListRoots(ctx, request.RawParams)
-	case protocol.SamplingCreateMessage:
-		result, err = client.handleRequestWithCreateMessagesSampling(ctx, request.RawParams)
-	default:
-		err = fmt.Errorf("%w: method=%s", pkg.ErrMethodNotSupport, request.Method)
+	if err = client.transport.Send(ctx, message); err != nil {
+		return fmt.Errorf("sendResponse: transport send: %w", err)
 	}
+	return nil
+}
 
+// handleJSONRPCRequest runs a server request through the interceptors and builds its response
+func (client *Client) handleJSONRPCRequest(ctx context.Context, request *protocol.JSONRPCRequest) *protocol.JSONRPCResponse {
+	result, err := client.requestHandler(ctx, request.Method, request.RawParams)
 	if err != nil {
-		switch {
-		case errors.Is(err, pkg.ErrMethodNotSupport):
-			return client.sendMsgWithError(ctx, request.ID, protocol.MethodNotFound, err.Error())
-		case errors.Is(err, pkg.ErrRequestInvalid):
-			return client.sendMsgWithError(ctx, request.ID, protocol.InvalidRequest, err.Error())
-		case errors.Is(err, pkg.ErrJSONUnmarshal):
-			return client.sendMsgWithError(ctx, request.ID, protocol.ParseError, err.Error())
-		default:
-			return client.sendMsgWithError(ctx, request.ID, protocol.InternalError, err.Error())
+		return protocol.NewJSONRPCErrorResponse(request.ID, errorCode(err), err.Error())
+	}
+	return protocol.NewJSONRPCSuccessResponse(request.ID, result)
+}
+
+// errorCode maps the error of a request to its JSON-RPC error code
+func errorCode(err error) int {
+	switch {
+	case errors.Is(err, pkg.ErrMethodNotSupport):
+		return protocol.MethodNotFound
+	case errors.Is(err, pkg.ErrRequestInvalid):
+		return protocol.InvalidRequest
+	case errors.Is(err, pkg.ErrInvalidParams):
+		return protocol.InvalidParams
+	case errors.Is(err, pkg.ErrJSONUnmarshal):
+		return protocol.ParseError
+	default:
+		return protocol.InternalError
+	}
+}
+
+// handleRequest dispatches a server request to its handler, it is the innermost RequestHandler of the interceptor chain
+func (client *Client) handleRequest(ctx context.Context, method protocol.Method, rawParams json.RawMessage) (protocol.ClientResponse, error) {
+	switch method {
+	case protocol.Ping:
+		return client.handleRequestWithPing()
+	case protocol.RootsList:
+		return client.handleRequestWithListRoots(ctx, rawParams)
+	case protocol.SamplingCreateMessage:
+		return client.handleRequestWithCreateMessagesSampling(ctx, rawParams)
+	case protocol.ElicitationCreate:
+		return client.handleRequestWithElicit(ctx, rawParams)
+	default:
+		handler, ok := client.methodHandlers.Load(string(method))
+		if !ok {
+			return nil, fmt.Errorf("%w: method=%s", pkg.ErrMethodNotSupport, method)
 		}
+		return handler(ctx, rawParams)
 	}
-	return client.sendMsgWithResponse(ctx, request.ID, result)
 }
 
 func (client *Client) receiveNotify(ctx context.Context, notify *protocol.JSONRPCNotification) error {
@@ -110,8 +211,16 @@ func (client *Client) receiveNotify(ctx context.Context, notify *protocol.JSONRP
 		return client.handleNotifyWithResourcesListChanged(ctx, notify.RawParams)
 	case protocol.NotificationResourcesUpdated:
 		return client.handleNotifyWithResourcesUpdated(ctx, notify.RawParams)
+	case protocol.NotificationProgress:
+		return client.handleNotifyWithProgress(ctx, notify.RawParams)
+	case protocol.NotificationLogMessage:
+		return client.handleNotifyWithLogMessage(ctx, notify.RawParams)
 	default:
-		return fmt.Errorf("%w: method=%s", pkg.ErrMethodNotSupport, notify.Method)
+		handler, ok := client.notificationHandlers.Load(string(notify.Method))
+		if !ok {
+			return fmt.Errorf("%w: method=%s", pkg.ErrMethodNotSupport, notify.Method)
+		}
+		return handler(ctx, notify.RawParams)
 	}
 }
 
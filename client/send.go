@@ -33,24 +33,6 @@ func (client *Client) sendMsgWithRequest(ctx context.Context, requestID protocol
 	return nil
 }
 
-func (client *Client) sendMsgWithResponse(ctx context.Context, requestID protocol.RequestID, result protocol.ClientResponse) error {
-	if requestID == nil {
-		return fmt.Errorf("requestID can't is nil")
-	}
-
-	resp := protocol.NewJSONRPCSuccessResponse(requestID, result)
-
-	message, err := json.Marshal(resp)
-	if err != nil {
-		return err
-	}
-
-	if err = client.transport.Send(ctx, message); err != nil {
-		return fmt.Errorf("sendResponse: transport send: %w", err)
-	}
-	return nil
-}
-
 func (client *Client) sendMsgWithNotification(ctx context.Context, method protocol.Method, params protocol.ClientNotify) error {
 	notify := protocol.NewJSONRPCNotification(method, params)
 
@@ -65,24 +47,6 @@ func (client *Client) sendMsgWithNotification(ctx context.Context, method protoc
 	return nil
 }
 
-func (client *Client) sendMsgWithError(ctx context.Context, requestID protocol.RequestID, code int, msg string) error {
-	if requestID == nil {
-		return fmt.Errorf("requestID can't is nil")
-	}
-
-	resp := protocol.NewJSONRPCErrorResponse(requestID, code, msg)
-
-	message, err := json.Marshal(resp)
-	if err != nil {
-		return err
-	}
-
-	if err = client.transport.Send(ctx, message); err != nil {
-		return fmt.Errorf("sendResponse: transport send: %w", err)
-	}
-	return nil
-}
-
 func (client *Client) againInitialization(ctx context.Context) error {
 	client.ready.Store(false)
 
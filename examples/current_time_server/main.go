@@ -32,15 +32,11 @@ func main() {
 		log.Fatalf("Failed to create server: %v", err)
 	}
 
-	// new protocol tool with name, descipriton and properties
-	tool, err := protocol.NewTool("current_time", "Get current time with timezone, Asia/Shanghai is default", currentTimeReq{})
-	if err != nil {
-		log.Fatalf("Failed to create tool: %v", err)
+	// register tool with name and descipriton, its properties are generated from currentTimeReq, and start mcp server
+	if err = server.RegisterTypedTool(srv, "current_time", "Get current time with timezone, Asia/Shanghai is default", currentTime); err != nil {
+		log.Fatalf("Failed to register tool: %v", err)
 		return
 	}
-
-	// register tool and start mcp server
-	srv.RegisterTool(tool, currentTime)
 	// srv.RegisterResource()
 	// srv.RegisterPrompt()
 	// srv.RegisterResourceTemplate()
@@ -89,26 +85,12 @@ func getTransport() (t transport.ServerTransport) {
 	return t
 }
 
-func currentTime(_ context.Context, request *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
-	req := new(currentTimeReq)
-	if err := protocol.VerifyAndUnmarshal(request.RawArguments, &req); err != nil {
-		return nil, err
-	}
-
+func currentTime(_ context.Context, req currentTimeReq) (string, error) {
 	loc, err := time.LoadLocation(req.Timezone)
 	if err != nil {
-		return nil, fmt.Errorf("parse timezone with error: %v", err)
+		return "", fmt.Errorf("parse timezone with error: %v", err)
 	}
-	text := fmt.Sprintf(`current time is %s`, time.Now().In(loc))
-
-	return &protocol.CallToolResult{
-		Content: []protocol.Content{
-			&protocol.TextContent{
-				Type: "text",
-				Text: text,
-			},
-		},
-	}, nil
+	return fmt.Sprintf(`current time is %s`, time.Now().In(loc)), nil
 }
 
 func signalWaiter(errCh chan error) error {
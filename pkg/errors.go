@@ -9,6 +9,7 @@ var (
 	ErrClientNotSupport          = errors.New("this feature client not support")
 	ErrServerNotSupport          = errors.New("this feature server not support")
 	ErrRequestInvalid            = errors.New("request invalid")
+	ErrInvalidParams             = errors.New("invalid params")
 	ErrLackResponseChan          = errors.New("lack response chan")
 	ErrDuplicateResponseReceived = errors.New("duplicate response received")
 	ErrMethodNotSupport          = errors.New("method not support")
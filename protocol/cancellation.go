@@ -2,8 +2,9 @@ package protocol
 
 // CancelledNotification represents a notification that a request has been canceled
 type CancelledNotification struct {
-	RequestID RequestID `json:"requestId"`
-	Reason    string    `json:"reason,omitempty"`
+	Meta      map[string]interface{} `json:"_meta,omitempty"`
+	RequestID RequestID              `json:"requestId"`
+	Reason    string                 `json:"reason,omitempty"`
 }
 
 // NewCancelledNotification creates a new canceled notification
@@ -1,12 +1,71 @@
 package protocol
 
+import (
+	"encoding/json"
+
+	"github.com/tidwall/gjson"
+
+	"github.com/ThinkInAIXYZ/go-mcp/pkg"
+)
+
+const (
+	PromptReferenceType   = "ref/prompt"
+	ResourceReferenceType = "ref/resource"
+)
+
 // CompleteRequest represents a request for completion options
 type CompleteRequest struct {
-	Argument struct {
-		Name  string `json:"name"`
-		Value string `json:"value"`
-	} `json:"argument"`
-	Ref interface{} `json:"ref"` // Can be PromptReference or ResourceReference
+	Meta     map[string]interface{} `json:"_meta,omitempty"`
+	Argument CompleteArgument       `json:"argument"`
+	Ref      interface{}            `json:"ref"` // Can be PromptReference or ResourceReference
+}
+
+// CompleteArgument The argument's information
+type CompleteArgument struct {
+	// Name The name of the argument
+	Name string `json:"name"`
+	// Value The value of the argument to use for completion matching.
+	Value string `json:"value"`
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface for CompleteRequest,
+// the reference is decoded into PromptReference or ResourceReference according to its type.
+// A reference of any other type is kept as its json.RawMessage, and a missing one leaves Ref nil,
+// it is up to the receiver to reject them.
+func (r *CompleteRequest) UnmarshalJSON(data []byte) error {
+	type Alias CompleteRequest
+	aux := &struct {
+		Ref json.RawMessage `json:"ref"`
+		*Alias
+	}{
+		Alias: (*Alias)(r),
+	}
+	if err := pkg.JSONUnmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	if len(aux.Ref) == 0 || string(aux.Ref) == "null" {
+		r.Ref = nil
+		return nil
+	}
+
+	switch gjson.GetBytes(aux.Ref, "type").String() {
+	case PromptReferenceType:
+		var ref PromptReference
+		if err := pkg.JSONUnmarshal(aux.Ref, &ref); err != nil {
+			return err
+		}
+		r.Ref = ref
+	case ResourceReferenceType:
+		var ref ResourceReference
+		if err := pkg.JSONUnmarshal(aux.Ref, &ref); err != nil {
+			return err
+		}
+		r.Ref = ref
+	default:
+		r.Ref = aux.Ref
+	}
+	return nil
 }
 
 // Reference types
@@ -22,7 +81,8 @@ type ResourceReference struct {
 
 // CompleteResult represents the response to a completion request
 type CompleteResult struct {
-	Completion Complete `json:"completion"`
+	Meta       map[string]interface{} `json:"_meta,omitempty"`
+	Completion Complete               `json:"completion"`
 }
 
 type Complete struct {
@@ -31,13 +91,20 @@ type Complete struct {
 	Total   int      `json:"total,omitempty"`
 }
 
+// NewPromptReference creates a reference to a prompt
+func NewPromptReference(name string) *PromptReference {
+	return &PromptReference{Type: PromptReferenceType, Name: name}
+}
+
+// NewResourceReference creates a reference to a resource or resource template
+func NewResourceReference(uri string) *ResourceReference {
+	return &ResourceReference{Type: ResourceReferenceType, URI: uri}
+}
+
 // NewCompleteRequest creates a new completion request
 func NewCompleteRequest(argName string, argValue string, ref interface{}) *CompleteRequest {
 	return &CompleteRequest{
-		Argument: struct {
-			Name  string `json:"name"`
-			Value string `json:"value"`
-		}{
+		Argument: CompleteArgument{
 			Name:  argName,
 			Value: argValue,
 		},
@@ -48,11 +115,7 @@ func NewCompleteRequest(argName string, argValue string, ref interface{}) *Compl
 // NewCompleteResult creates a new completion response
 func NewCompleteResult(values []string, hasMore bool, total int) *CompleteResult {
 	return &CompleteResult{
-		Completion: struct {
-			Values  []string `json:"values"`
-			HasMore bool     `json:"hasMore,omitempty"`
-			Total   int      `json:"total,omitempty"`
-		}{
+		Completion: Complete{
 			Values:  values,
 			HasMore: hasMore,
 			Total:   total,
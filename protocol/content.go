@@ -0,0 +1,93 @@
+package protocol
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/tidwall/gjson"
+
+	"github.com/ThinkInAIXYZ/go-mcp/pkg"
+)
+
+// contentTypes maps the "type" field of a content object to a constructor of its Go type
+var contentTypes = pkg.SyncMap[func() Content]{}
+
+func init() {
+	RegisterContentType("text", func() Content { return &TextContent{} })
+	RegisterContentType("image", func() Content { return &ImageContent{} })
+	RegisterContentType("audio", func() Content { return &AudioContent{} })
+	RegisterContentType("resource", func() Content { return &EmbeddedResource{} })
+	RegisterContentType("resource_link", func() Content { return &ResourceLink{} })
+}
+
+// RegisterContentType registers the Go type used to decode content whose "type" field is typ,
+// newContent must return a pointer that json can unmarshal into. Registering an existing type replaces it.
+func RegisterContentType(typ string, newContent func() Content) {
+	contentTypes.Store(typ, newContent)
+}
+
+// RawContent holds a content whose type isn't registered, such as one added by a newer protocol version,
+// it is marshaled back exactly as it was received.
+type RawContent struct {
+	Type string
+	Raw  json.RawMessage
+}
+
+func (c *RawContent) GetType() string {
+	return c.Type
+}
+
+// MarshalJSON implements the json.Marshaler interface for RawContent
+func (c *RawContent) MarshalJSON() ([]byte, error) {
+	return c.Raw, nil
+}
+
+// UnmarshalContent decodes a content object into the type registered for its "type" field,
+// a content of an unregistered type is decoded into a RawContent.
+func UnmarshalContent(data []byte) (Content, error) {
+	typ := gjson.GetBytes(data, "type")
+	if !typ.Exists() {
+		return nil, fmt.Errorf("content type is missing, content=%s", data)
+	}
+	newContent, ok := contentTypes.Load(typ.String())
+	if !ok {
+		return &RawContent{Type: typ.String(), Raw: append(json.RawMessage(nil), data...)}, nil
+	}
+	content := newContent()
+	if err := pkg.JSONUnmarshal(data, content); err != nil {
+		return nil, fmt.Errorf("unmarshal %s content: %w", typ.String(), err)
+	}
+	return content, nil
+}
+
+func unmarshalContents(data []json.RawMessage) ([]Content, error) {
+	contents := make([]Content, len(data))
+	for i, raw := range data {
+		content, err := UnmarshalContent(raw)
+		if err != nil {
+			return nil, fmt.Errorf("content at index %d: %w", i, err)
+		}
+		contents[i] = content
+	}
+	return contents, nil
+}
+
+// UnmarshalResourceContents decodes resource contents, a "blob" field selects BlobResourceContents and a "text" field TextResourceContents
+func UnmarshalResourceContents(data []byte) (ResourceContents, error) {
+	switch {
+	case gjson.GetBytes(data, "blob").Exists():
+		var blob BlobResourceContents
+		if err := pkg.JSONUnmarshal(data, &blob); err != nil {
+			return nil, err
+		}
+		return blob, nil
+	case gjson.GetBytes(data, "text").Exists():
+		var text TextResourceContents
+		if err := pkg.JSONUnmarshal(data, &text); err != nil {
+			return nil, err
+		}
+		return text, nil
+	default:
+		return nil, fmt.Errorf("resource contents must have a text or blob field, content=%s", data)
+	}
+}
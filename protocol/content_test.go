@@ -0,0 +1,114 @@
+package protocol
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+type testChartContent struct {
+	Type   string    `json:"type"`
+	Series []float64 `json:"series"`
+}
+
+func (c *testChartContent) GetType() string {
+	return "chart"
+}
+
+func TestCallToolResultContent(t *testing.T) {
+	result := NewCallToolResult([]Content{
+		&ImageContent{Type: "image", Data: []byte("png"), MimeType: "image/png"},
+		NewEmbeddedResource(TextResourceContents{URI: "file:///a.txt", Text: "a"}, nil),
+		&AudioContent{Type: "audio", Data: []byte("wav"), MimeType: "audio/wav"},
+		NewResourceLink(Resource{URI: "file:///b.txt", Name: "b.txt", MimeType: "text/plain", Size: 1024}),
+		&TextContent{Type: "text", Text: "done"},
+	}, false)
+
+	b, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("json Marshal: %+v", err)
+	}
+	var got CallToolResult
+	if err = json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("json Unmarshal: %+v", err)
+	}
+	if !reflect.DeepEqual(&got, result) {
+		t.Fatalf("result not as expected.\ngot  = %+v\nwant = %+v", got, result)
+	}
+
+	chartJSON := `{"type":"chart","series":[1,2]}`
+	if err = json.Unmarshal([]byte(`{"content":[`+chartJSON+`,{"type":"text","text":"done"}]}`), &got); err != nil {
+		t.Fatalf("json Unmarshal: %+v", err)
+	}
+	if raw, ok := got.Content[0].(*RawContent); !ok || raw.GetType() != "chart" {
+		t.Fatalf("unregistered content not as expected: %+v", got.Content[0])
+	}
+	if b, err = json.Marshal(got.Content[0]); err != nil || string(b) != chartJSON {
+		t.Fatalf("raw content should marshal as received: %s, %v", b, err)
+	}
+	RegisterContentType("chart", func() Content { return &testChartContent{} })
+	if err = json.Unmarshal([]byte(`{"content":[{"type":"chart","series":[1,2]}]}`), &got); err != nil {
+		t.Fatalf("json Unmarshal: %+v", err)
+	}
+	if chart, ok := got.Content[0].(*testChartContent); !ok || !reflect.DeepEqual(chart.Series, []float64{1, 2}) {
+		t.Fatalf("chart content not as expected: %+v", got.Content[0])
+	}
+
+	for _, invalid := range []string{
+		`{"content":[{"text":"no type"}]}`,
+		`{"content":[{"type":"resource","resource":{"uri":"file:///a.txt"}}]}`,
+	} {
+		if err = json.Unmarshal([]byte(invalid), &got); err == nil {
+			t.Errorf("json Unmarshal(%s) should fail", invalid)
+		}
+	}
+}
+
+func TestMessageContent(t *testing.T) {
+	var prompt PromptMessage
+	if err := json.Unmarshal([]byte(`{"role":"user","content":{"type":"image","data":"cG5n","mimeType":"image/png"}}`), &prompt); err != nil {
+		t.Fatalf("json Unmarshal: %+v", err)
+	}
+	if image, ok := prompt.Content.(*ImageContent); !ok || string(image.Data) != "png" {
+		t.Fatalf("prompt content not as expected: %+v", prompt.Content)
+	}
+
+	var sampling CreateMessageResult
+	if err := json.Unmarshal([]byte(`{"role":"assistant","model":"m","content":{"type":"audio","data":"d2F2","mimeType":"audio/wav"}}`), &sampling); err != nil {
+		t.Fatalf("json Unmarshal: %+v", err)
+	}
+	if audio, ok := sampling.Content.(*AudioContent); !ok || string(audio.Data) != "wav" {
+		t.Fatalf("sampling content not as expected: %+v", sampling.Content)
+	}
+}
+
+func TestReadResourceResultContents(t *testing.T) {
+	result := NewReadResourceResult([]ResourceContents{
+		BlobResourceContents{URI: "file:///a.png", Blob: []byte("png"), MimeType: "image/png"},
+		TextResourceContents{URI: "file:///a.txt", Text: "a"},
+		TextResourceContents{URI: "file:///empty.txt", Text: ""},
+	})
+
+	b, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("json Marshal: %+v", err)
+	}
+	var got ReadResourceResult
+	if err = json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("json Unmarshal: %+v", err)
+	}
+	if !reflect.DeepEqual(&got, result) {
+		t.Fatalf("result not as expected.\ngot  = %+v\nwant = %+v", got, result)
+	}
+}
+
+func TestUnmarshalResourceLink(t *testing.T) {
+	content, err := UnmarshalContent([]byte(`{"type":"resource_link","uri":"file:///a.go","name":"a.go","description":"entry point","mimeType":"text/x-go","size":2048}`))
+	if err != nil {
+		t.Fatalf("UnmarshalContent: %+v", err)
+	}
+	expected := &ResourceLink{Type: "resource_link", Resource: Resource{URI: "file:///a.go", Name: "a.go", Description: "entry point", MimeType: "text/x-go", Size: 2048}}
+	if !reflect.DeepEqual(content, expected) {
+		t.Fatalf("resource link not as expected.\ngot  = %+v\nwant = %+v", content, expected)
+	}
+}
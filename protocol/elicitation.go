@@ -0,0 +1,92 @@
+package protocol
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ElicitRequest is sent from the server to ask the user for structured input through the client
+type ElicitRequest struct {
+	Meta map[string]interface{} `json:"_meta,omitempty"`
+	// Message is presented to the user to explain what information is requested
+	Message string `json:"message"`
+	// RequestedSchema describes the expected response, only top-level properties of primitive types are allowed
+	RequestedSchema RequestedSchema `json:"requestedSchema"`
+}
+
+// RequestedSchema is a restricted JSON Schema: a flat object whose properties are strings, numbers, integers or booleans
+type RequestedSchema = InputSchema
+
+type ElicitAction string
+
+const (
+	// ElicitAccept the user submitted the requested data
+	ElicitAccept ElicitAction = "accept"
+	// ElicitDecline the user explicitly refused to provide the data
+	ElicitDecline ElicitAction = "decline"
+	// ElicitCancel the user dismissed the request without making a choice
+	ElicitCancel ElicitAction = "cancel"
+)
+
+// ElicitResult is the client's response to an elicitation/create request
+type ElicitResult struct {
+	Meta   map[string]interface{} `json:"_meta,omitempty"`
+	Action ElicitAction           `json:"action"`
+	// Content holds the submitted data when Action is ElicitAccept
+	Content map[string]interface{} `json:"content,omitempty"`
+}
+
+// NewElicitRequest creates a new elicit request, the requested schema is generated from schemaStruct the same way NewTool does
+func NewElicitRequest(message string, schemaStruct interface{}) (*ElicitRequest, error) {
+	schema, err := generateSchemaFromReqStruct(schemaStruct)
+	if err != nil {
+		return nil, err
+	}
+	if err = verifyRequestedSchema(schema); err != nil {
+		return nil, err
+	}
+
+	return &ElicitRequest{
+		Message:         message,
+		RequestedSchema: *schema,
+	}, nil
+}
+
+// NewElicitResult creates a new elicit response
+func NewElicitResult(action ElicitAction, content map[string]interface{}) *ElicitResult {
+	return &ElicitResult{
+		Action:  action,
+		Content: content,
+	}
+}
+
+// VerifyContent checks the content of an accepted result against the requested schema
+func (r *ElicitRequest) VerifyContent(result *ElicitResult) error {
+	if result.Action != ElicitAccept {
+		return nil
+	}
+
+	schema, err := compileProperty(&Property{Type: ObjectT, Properties: r.RequestedSchema.Properties, Required: r.RequestedSchema.Required})
+	if err != nil {
+		return err
+	}
+	content, err := json.Marshal(result.Content)
+	if err != nil {
+		return err
+	}
+	if err = schema.ValidateJSON(content); err != nil {
+		return fmt.Errorf("elicitation content validation failed against the requested schema: %w", err)
+	}
+	return nil
+}
+
+func verifyRequestedSchema(schema *RequestedSchema) error {
+	for name, property := range schema.Properties {
+		switch property.Type {
+		case String, Number, Integer, Boolean:
+		default:
+			return fmt.Errorf("elicitation schema only supports primitive properties, property %s has type %s", name, property.Type)
+		}
+	}
+	return nil
+}
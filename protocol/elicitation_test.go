@@ -0,0 +1,43 @@
+package protocol
+
+import (
+	"testing"
+)
+
+func TestNewElicitRequest(t *testing.T) {
+	type deployParams struct {
+		Environment string `json:"environment" enum:"staging,production"`
+		Replicas    int    `json:"replicas,omitempty"`
+		Confirm     bool   `json:"confirm"`
+	}
+	type nestedParams struct {
+		Tags []string `json:"tags"`
+	}
+
+	request, err := NewElicitRequest("confirm the deployment", deployParams{})
+	if err != nil {
+		t.Fatalf("NewElicitRequest: %+v", err)
+	}
+
+	tests := []struct {
+		name        string
+		result      *ElicitResult
+		expectedErr bool
+	}{
+		{name: "accept", result: NewElicitResult(ElicitAccept, map[string]interface{}{"environment": "staging", "confirm": true})},
+		{name: "accept_invalid_enum", result: NewElicitResult(ElicitAccept, map[string]interface{}{"environment": "dev", "confirm": true}), expectedErr: true},
+		{name: "accept_missing_required", result: NewElicitResult(ElicitAccept, map[string]interface{}{"environment": "staging"}), expectedErr: true},
+		{name: "decline", result: NewElicitResult(ElicitDecline, nil)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := request.VerifyContent(tt.result); (err != nil) != tt.expectedErr {
+				t.Fatalf("VerifyContent error not as expected: %+v", err)
+			}
+		})
+	}
+
+	if _, err = NewElicitRequest("tags", nestedParams{}); err == nil {
+		t.Fatalf("NewElicitRequest should reject non-primitive properties")
+	}
+}
@@ -2,17 +2,19 @@ package protocol
 
 // InitializeRequest represents the initialize request sent from client to server
 type InitializeRequest struct {
-	ClientInfo      Implementation     `json:"clientInfo"`
-	Capabilities    ClientCapabilities `json:"capabilities"`
-	ProtocolVersion string             `json:"protocolVersion"`
+	Meta            map[string]interface{} `json:"_meta,omitempty"`
+	ClientInfo      Implementation         `json:"clientInfo"`
+	Capabilities    ClientCapabilities     `json:"capabilities"`
+	ProtocolVersion string                 `json:"protocolVersion"`
 }
 
 // InitializeResult represents the server's response to an initialize request
 type InitializeResult struct {
-	ServerInfo      Implementation     `json:"serverInfo"`
-	Capabilities    ServerCapabilities `json:"capabilities"`
-	ProtocolVersion string             `json:"protocolVersion"`
-	Instructions    string             `json:"instructions,omitempty"`
+	Meta            map[string]interface{} `json:"_meta,omitempty"`
+	ServerInfo      Implementation         `json:"serverInfo"`
+	Capabilities    ServerCapabilities     `json:"capabilities"`
+	ProtocolVersion string                 `json:"protocolVersion"`
+	Instructions    string                 `json:"instructions,omitempty"`
 }
 
 // Implementation describes the name and version of an MCP implementation
@@ -23,9 +25,10 @@ type Implementation struct {
 
 // ClientCapabilities capabilities
 type ClientCapabilities struct {
-	// Experimental map[string]interface{} `json:"experimental,omitempty"`
-	// Roots        *RootsCapability       `json:"roots,omitempty"`
-	Sampling interface{} `json:"sampling,omitempty"`
+	Experimental map[string]interface{} `json:"experimental,omitempty"`
+	Roots        *RootsCapability       `json:"roots,omitempty"`
+	Sampling     interface{}            `json:"sampling,omitempty"`
+	Elicitation  interface{}            `json:"elicitation,omitempty"`
 }
 
 type RootsCapability struct {
@@ -33,11 +36,12 @@ type RootsCapability struct {
 }
 
 type ServerCapabilities struct {
-	// Experimental map[string]interface{} `json:"experimental,omitempty"`
-	// Logging      interface{}            `json:"logging,omitempty"`
-	Prompts   *PromptsCapability   `json:"prompts,omitempty"`
-	Resources *ResourcesCapability `json:"resources,omitempty"`
-	Tools     *ToolsCapability     `json:"tools,omitempty"`
+	Experimental map[string]interface{} `json:"experimental,omitempty"`
+	Logging      interface{}            `json:"logging,omitempty"`
+	Completions  interface{}            `json:"completions,omitempty"`
+	Prompts      *PromptsCapability     `json:"prompts,omitempty"`
+	Resources    *ResourcesCapability   `json:"resources,omitempty"`
+	Tools        *ToolsCapability       `json:"tools,omitempty"`
 }
 
 type PromptsCapability struct {
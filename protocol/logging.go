@@ -1,5 +1,7 @@
 package protocol
 
+import "github.com/ThinkInAIXYZ/go-mcp/pkg"
+
 // LoggingLevel represents the severity of a log message
 type LoggingLevel string
 
@@ -14,21 +16,67 @@ const (
 	LogDebug     LoggingLevel = "debug"
 )
 
+// loggingLevelSeverity orders the levels as in RFC 5424, a larger value is more severe
+var loggingLevelSeverity = map[LoggingLevel]int{
+	LogDebug:     0,
+	LogInfo:      1,
+	LogNotice:    2,
+	LogWarning:   3,
+	LogError:     4,
+	LogCritical:  5,
+	LogAlert:     6,
+	LogEmergency: 7,
+}
+
+// IsValid reports whether the level is one of the levels defined by the protocol
+func (l LoggingLevel) IsValid() bool {
+	_, ok := loggingLevelSeverity[l]
+	return ok
+}
+
+// Enabled reports whether a message at level l should be sent to a peer that asked for minLevel
+func (l LoggingLevel) Enabled(minLevel LoggingLevel) bool {
+	if !l.IsValid() || !minLevel.IsValid() {
+		return false
+	}
+	return loggingLevelSeverity[l] >= loggingLevelSeverity[minLevel]
+}
+
 // SetLoggingLevelRequest represents a request to set the logging level
 type SetLoggingLevelRequest struct {
-	Level LoggingLevel `json:"level"`
+	Meta  map[string]interface{} `json:"_meta,omitempty"`
+	Level LoggingLevel           `json:"level"`
 }
 
 // SetLoggingLevelResult represents the response to a set logging level request
 type SetLoggingLevelResult struct {
-	Success bool `json:"success"`
+	Meta map[string]interface{} `json:"_meta,omitempty"`
 }
 
 // LogMessageNotification represents a log message notification
 type LogMessageNotification struct {
-	Level   LoggingLevel           `json:"level"`
-	Message string                 `json:"message"`
-	Meta    map[string]interface{} `json:"meta,omitempty"`
+	Level LoggingLevel `json:"level"`
+	// Logger An optional name of the logger issuing this message.
+	Logger string `json:"logger,omitempty"`
+	// Data The data to be logged, such as a string message or an object. Any JSON serializable type is allowed here.
+	Data interface{}            `json:"data"`
+	Meta map[string]interface{} `json:"_meta,omitempty"`
+
+	// Deprecated: Message is the message given to NewLogMessageNotification, the message is sent as Data.
+	// A received notification whose data is a string has it in Message too.
+	Message string `json:"-"`
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface for LogMessageNotification
+func (n *LogMessageNotification) UnmarshalJSON(data []byte) error {
+	type Alias LogMessageNotification
+	if err := pkg.JSONUnmarshal(data, (*Alias)(n)); err != nil {
+		return err
+	}
+	if message, ok := n.Data.(string); ok {
+		n.Message = message
+	}
+	return nil
 }
 
 // NewSetLoggingLevelRequest creates a new set logging level request
@@ -39,17 +87,29 @@ func NewSetLoggingLevelRequest(level LoggingLevel) *SetLoggingLevelRequest {
 }
 
 // NewSetLoggingLevelResult creates a new set logging level response
-func NewSetLoggingLevelResult(success bool) *SetLoggingLevelResult {
-	return &SetLoggingLevelResult{
-		Success: success,
-	}
+//
+// Deprecated: the result of logging/setLevel is an empty object, success is ignored.
+func NewSetLoggingLevelResult(_ bool) *SetLoggingLevelResult {
+	return &SetLoggingLevelResult{}
 }
 
-// NewLogMessageNotification creates a new log message notification
+// NewLogMessageNotification creates a new log message notification, message is sent as its data
+//
+// Deprecated: use NewLogMessageNotificationWithData, which also sets the logger and accepts any data.
 func NewLogMessageNotification(level LoggingLevel, message string, meta map[string]interface{}) *LogMessageNotification {
 	return &LogMessageNotification{
 		Level:   level,
-		Message: message,
+		Data:    message,
 		Meta:    meta,
+		Message: message,
+	}
+}
+
+// NewLogMessageNotificationWithData creates a new log message notification issued by the named logger with any data
+func NewLogMessageNotificationWithData(level LoggingLevel, logger string, data interface{}) *LogMessageNotification {
+	return &LogMessageNotification{
+		Level:  level,
+		Logger: logger,
+		Data:   data,
 	}
 }
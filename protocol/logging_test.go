@@ -0,0 +1,50 @@
+package protocol
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestLoggingJSON(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		value    interface{}
+		expected string
+	}{
+		{
+			name:     "set_level_result",
+			value:    NewSetLoggingLevelResult(true),
+			expected: `{}`,
+		},
+		{
+			name:     "message",
+			value:    NewLogMessageNotification(LogInfo, "started", nil),
+			expected: `{"level":"info","data":"started"}`,
+		},
+		{
+			name:     "logger_message",
+			value:    NewLogMessageNotificationWithData(LogError, "db", map[string]interface{}{"error": "boom"}),
+			expected: `{"level":"error","logger":"db","data":{"error":"boom"}}`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := json.Marshal(tt.value)
+			if err != nil {
+				t.Fatalf("json Marshal: %+v", err)
+			}
+			if string(b) != tt.expected {
+				t.Fatalf("json not as expected.\ngot  = %s\nwant = %s", b, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLogMessageNotificationMessage(t *testing.T) {
+	var notify LogMessageNotification
+	if err := json.Unmarshal([]byte(`{"level":"info","data":"started"}`), &notify); err != nil {
+		t.Fatalf("json Unmarshal: %+v", err)
+	}
+	if notify.Data != "started" || notify.Message != "started" {
+		t.Fatalf("notification not as expected: %+v", notify)
+	}
+}
@@ -2,6 +2,8 @@ package protocol
 
 // PaginatedRequest represents a request that supports pagination
 type PaginatedRequest struct {
+	// Cursor An opaque token representing the current pagination position.
+	// If provided, the server should return results starting after this cursor.
 	Cursor string `json:"cursor,omitempty"`
 }
 
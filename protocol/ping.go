@@ -1,8 +1,12 @@
 package protocol
 
-type PingRequest struct{}
+type PingRequest struct {
+	Meta map[string]interface{} `json:"_meta,omitempty"`
+}
 
-type PingResult struct{}
+type PingResult struct {
+	Meta map[string]interface{} `json:"_meta,omitempty"`
+}
 
 // NewPingRequest creates a new ping request
 func NewPingRequest() *PingRequest {
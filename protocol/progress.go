@@ -2,14 +2,20 @@ package protocol
 
 // ProgressNotification represents a progress notification for a long-running request
 type ProgressNotification struct {
-	ProgressToken ProgressToken `json:"progressToken"`
-	Progress      float64       `json:"progress"`
-	Total         float64       `json:"total,omitempty"`
+	Meta          map[string]interface{} `json:"_meta,omitempty"`
+	ProgressToken ProgressToken          `json:"progressToken"`
+	Progress      float64                `json:"progress"`
+	Total         float64                `json:"total,omitempty"`
+	// Message An optional message describing the current progress.
+	Message string `json:"message,omitempty"`
 }
 
 // ProgressToken represents a token used to associate progress notifications with the original request
 type ProgressToken interface{} // can be string or integer
 
+// ProgressTokenMetaKey is the key of the progress token in a request's _meta
+const ProgressTokenMetaKey = "progressToken"
+
 // NewProgressNotification creates a new progress notification
 func NewProgressNotification(token ProgressToken, progress float64, total float64) *ProgressNotification {
 	return &ProgressNotification{
@@ -18,3 +24,13 @@ func NewProgressNotification(token ProgressToken, progress float64, total float6
 		Total:         total,
 	}
 }
+
+// NewProgressNotificationWithMessage creates a new progress notification describing the current progress with message
+func NewProgressNotificationWithMessage(token ProgressToken, progress float64, total float64, message string) *ProgressNotification {
+	return &ProgressNotification{
+		ProgressToken: token,
+		Progress:      progress,
+		Total:         total,
+		Message:       message,
+	}
+}
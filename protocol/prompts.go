@@ -3,17 +3,24 @@ package protocol
 import (
 	"encoding/json"
 	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
 
 	"github.com/ThinkInAIXYZ/go-mcp/pkg"
 )
 
 // ListPromptsRequest represents a request to list available prompts
-type ListPromptsRequest struct{}
+type ListPromptsRequest struct {
+	Meta map[string]interface{} `json:"_meta,omitempty"`
+	PaginatedRequest
+}
 
 // ListPromptsResult represents the response to a list prompts request
 type ListPromptsResult struct {
-	Prompts    []Prompt `json:"prompts"`
-	NextCursor string   `json:"nextCursor,omitempty"`
+	Meta       map[string]interface{} `json:"_meta,omitempty"`
+	Prompts    []Prompt               `json:"prompts"`
+	NextCursor string                 `json:"nextCursor,omitempty"`
 }
 
 // Prompt related types
@@ -31,14 +38,16 @@ type PromptArgument struct {
 
 // GetPromptRequest represents a request to get a specific prompt
 type GetPromptRequest struct {
-	Name      string            `json:"name"`
-	Arguments map[string]string `json:"arguments,omitempty"`
+	Meta      map[string]interface{} `json:"_meta,omitempty"`
+	Name      string                 `json:"name"`
+	Arguments map[string]string      `json:"arguments,omitempty"`
 }
 
 // GetPromptResult represents the response to a get prompt request
 type GetPromptResult struct {
-	Messages    []PromptMessage `json:"messages"`
-	Description string          `json:"description,omitempty"`
+	Meta        map[string]interface{} `json:"_meta,omitempty"`
+	Messages    []PromptMessage        `json:"messages"`
+	Description string                 `json:"description,omitempty"`
 }
 
 type PromptMessage struct {
@@ -59,35 +68,12 @@ func (m *PromptMessage) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
-	// Try to unmarshal content as TextContent first
-	var textContent *TextContent
-	if err := pkg.JSONUnmarshal(aux.Content, &textContent); err == nil {
-		m.Content = textContent
-		return nil
-	}
-
-	// Try to unmarshal content as ImageContent
-	var imageContent *ImageContent
-	if err := pkg.JSONUnmarshal(aux.Content, &imageContent); err == nil {
-		m.Content = imageContent
-		return nil
-	}
-
-	// Try to unmarshal content as AudioContent
-	var audioContent *AudioContent
-	if err := pkg.JSONUnmarshal(aux.Content, &audioContent); err == nil {
-		m.Content = audioContent
-		return nil
-	}
-
-	// Try to unmarshal content as embeddedResource
-	var embeddedResource *EmbeddedResource
-	if err := pkg.JSONUnmarshal(aux.Content, &embeddedResource); err == nil {
-		m.Content = embeddedResource
-		return nil
+	content, err := UnmarshalContent(aux.Content)
+	if err != nil {
+		return err
 	}
-
-	return fmt.Errorf("unknown content type")
+	m.Content = content
+	return nil
 }
 
 // PromptListChangedNotification represents a notification that the prompt list has changed
@@ -100,6 +86,12 @@ func NewListPromptsRequest() *ListPromptsRequest {
 	return &ListPromptsRequest{}
 }
 
+// WithCursor sets the cursor of the page to list, as returned in NextCursor by the previous page
+func (r *ListPromptsRequest) WithCursor(cursor string) *ListPromptsRequest {
+	r.Cursor = cursor
+	return r
+}
+
 // NewListPromptsResult creates a new list prompts response
 func NewListPromptsResult(prompts []Prompt, nextCursor string) *ListPromptsResult {
 	return &ListPromptsResult{
@@ -124,6 +116,161 @@ func NewGetPromptResult(messages []PromptMessage, description string) *GetPrompt
 	}
 }
 
+// NewPromptArguments builds the arguments of a prompt from the fields of argsStruct, a struct or a pointer to one.
+// Fields are read like the properties of a tool's input schema (see structFieldsOf): the fields of embedded structs are flattened,
+// and an enum tag restricts the values UnmarshalPromptArguments accepts. Prompt arguments are strings, so fields must be strings, booleans or numbers.
+func NewPromptArguments(argsStruct interface{}) ([]PromptArgument, error) {
+	fields, err := promptArgumentFieldsOf(reflect.TypeOf(argsStruct))
+	if err != nil {
+		return nil, err
+	}
+
+	arguments := make([]PromptArgument, 0, len(fields))
+	for _, field := range fields {
+		arguments = append(arguments, PromptArgument{Name: field.Name, Description: field.Description, Required: field.Required})
+	}
+	return arguments, nil
+}
+
+// UnmarshalPromptArguments checks that every required argument is present and decodes the arguments into v,
+// a pointer to the struct the prompt arguments were built from with NewPromptArguments.
+func UnmarshalPromptArguments(arguments map[string]string, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("invalid type %T, a non-nil pointer is required", v)
+	}
+
+	fields, err := promptArgumentFieldsOf(rv.Type())
+	if err != nil {
+		return err
+	}
+
+	rv = rv.Elem()
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		rv = rv.Elem()
+	}
+
+	var missing []string
+	for _, field := range fields {
+		value, ok := arguments[field.Name]
+		if !ok {
+			if field.Required {
+				missing = append(missing, field.Name)
+			}
+			continue
+		}
+		if field.Enum != nil && !containsString(field.Enum, value) {
+			return fmt.Errorf("argument %s: %q is not one of %s", field.Name, value, strings.Join(field.Enum, ", "))
+		}
+		target, err := fieldByIndexAlloc(rv, field.Index)
+		if err != nil {
+			return fmt.Errorf("argument %s: %w", field.Name, err)
+		}
+		if err = setPromptArgument(target, value); err != nil {
+			return fmt.Errorf("argument %s: %w", field.Name, err)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing required arguments: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// promptArgumentFieldsOf returns the fields of the struct type t (see structFieldsOf), which must all be scalars
+func promptArgumentFieldsOf(t reflect.Type) ([]structField, error) {
+	if t == nil {
+		return nil, fmt.Errorf("invalid type %v", t)
+	}
+	t = indirectType(t)
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("invalid type %v", t)
+	}
+
+	fields, err := structFieldsOf(t)
+	if err != nil {
+		return nil, err
+	}
+	for _, field := range fields {
+		switch indirectType(field.Type).Kind() {
+		case reflect.String, reflect.Bool,
+			reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+		default:
+			return nil, fmt.Errorf("unsupported type %v for prompt argument %s", field.Type, field.Name)
+		}
+	}
+	return fields, nil
+}
+
+// fieldByIndexAlloc returns the field of v at index, allocating the nil embedded struct pointers on the way
+func fieldByIndexAlloc(v reflect.Value, index []int) (reflect.Value, error) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}, fmt.Errorf("cannot set embedded pointer to unexported struct %v", v.Type().Elem())
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func setPromptArgument(field reflect.Value, value string) error {
+	if field.Kind() == reflect.Ptr {
+		field.Set(reflect.New(field.Type().Elem()))
+		field = field.Elem()
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", value)
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", value)
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not a non-negative integer", value)
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not a number", value)
+		}
+		field.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %v", field.Type())
+	}
+	return nil
+}
+
 // NewPromptListChangedNotification creates a new prompt list changed notification
 func NewPromptListChangedNotification() *PromptListChangedNotification {
 	return &PromptListChangedNotification{}
@@ -0,0 +1,67 @@
+package protocol
+
+import (
+	"reflect"
+	"testing"
+)
+
+type testPromptCommonArgs struct {
+	Tone     string `json:"tone,omitempty" enum:"formal,casual"`
+	Language string `json:"language" description:"shadowed by testReviewArgs.Language"`
+}
+
+type testReviewArgs struct {
+	testPromptCommonArgs
+	Code     string  `json:"code" description:"the code to review"`
+	Language string  `json:"language,omitempty" description:"programming language"`
+	Strict   bool    `json:"strict,omitempty"`
+	MaxItems *int    `json:"max_items,omitempty"`
+	Score    float64 `json:"score" required:"false"`
+	ignored  string
+	Skipped  string `json:"-"`
+}
+
+func TestPromptArguments(t *testing.T) {
+	arguments, err := NewPromptArguments(testReviewArgs{})
+	if err != nil {
+		t.Fatalf("NewPromptArguments: %+v", err)
+	}
+	expected := []PromptArgument{
+		{Name: "code", Description: "the code to review", Required: true},
+		{Name: "language", Description: "programming language"},
+		{Name: "strict"},
+		{Name: "max_items"},
+		{Name: "score"},
+		{Name: "tone"},
+	}
+	if !reflect.DeepEqual(arguments, expected) {
+		t.Fatalf("arguments not as expected.\ngot  = %+v\nwant = %+v", arguments, expected)
+	}
+
+	var args testReviewArgs
+	if err = UnmarshalPromptArguments(map[string]string{"code": "x := 1", "strict": "true", "max_items": "3", "score": "0.5", "tone": "casual"}, &args); err != nil {
+		t.Fatalf("UnmarshalPromptArguments: %+v", err)
+	}
+	maxItems := 3
+	expectedArgs := testReviewArgs{testPromptCommonArgs: testPromptCommonArgs{Tone: "casual"}, Code: "x := 1", Strict: true, MaxItems: &maxItems, Score: 0.5}
+	if expected := expectedArgs; !reflect.DeepEqual(args, expected) {
+		t.Fatalf("args not as expected.\ngot  = %+v\nwant = %+v", args, expected)
+	}
+
+	for _, invalid := range []map[string]string{
+		{"language": "go"},
+		{"code": "x", "strict": "maybe"},
+		{"code": "x", "max_items": "1.5"},
+		{"code": "x", "tone": "angry"},
+	} {
+		if err = UnmarshalPromptArguments(invalid, &testReviewArgs{}); err == nil {
+			t.Errorf("UnmarshalPromptArguments(%v) should fail", invalid)
+		}
+	}
+
+	if _, err = NewPromptArguments(struct {
+		Tags []string `json:"tags"`
+	}{}); err == nil {
+		t.Errorf("NewPromptArguments should reject non scalar fields")
+	}
+}
@@ -10,11 +10,15 @@ import (
 )
 
 // ListResourcesRequest Sent from the client to request a list of resources the server has.
-type ListResourcesRequest struct{}
+type ListResourcesRequest struct {
+	Meta map[string]interface{} `json:"_meta,omitempty"`
+	PaginatedRequest
+}
 
 // ListResourcesResult The server's response to a resources/list request from the client.
 type ListResourcesResult struct {
-	Resources []Resource `json:"resources"`
+	Meta      map[string]interface{} `json:"_meta,omitempty"`
+	Resources []Resource             `json:"resources"`
 	/**
 	 * An opaque token representing the pagination position after the last returned result.
 	 * If present, there may be more results available.
@@ -23,23 +27,29 @@ type ListResourcesResult struct {
 }
 
 // ListResourceTemplatesRequest represents a request to list resource templates
-type ListResourceTemplatesRequest struct{}
+type ListResourceTemplatesRequest struct {
+	Meta map[string]interface{} `json:"_meta,omitempty"`
+	PaginatedRequest
+}
 
 // ListResourceTemplatesResult represents the response to a list resource templates request
 type ListResourceTemplatesResult struct {
-	ResourceTemplates []ResourceTemplate `json:"resourceTemplates"`
-	NextCursor        string             `json:"nextCursor,omitempty"`
+	Meta              map[string]interface{} `json:"_meta,omitempty"`
+	ResourceTemplates []ResourceTemplate     `json:"resourceTemplates"`
+	NextCursor        string                 `json:"nextCursor,omitempty"`
 }
 
 // ReadResourceRequest represents a request to read a specific resource
 type ReadResourceRequest struct {
+	Meta      map[string]interface{} `json:"_meta,omitempty"`
 	URI       string                 `json:"uri"`
 	Arguments map[string]interface{} `json:"-"`
 }
 
 // ReadResourceResult The server's response to a resources/read request from the client.
 type ReadResourceResult struct {
-	Contents []ResourceContents `json:"contents"`
+	Meta     map[string]interface{} `json:"_meta,omitempty"`
+	Contents []ResourceContents     `json:"contents"`
 }
 
 // UnmarshalJSON implements the json.Unmarshaler interface for ReadResourceResult
@@ -57,23 +67,12 @@ func (r *ReadResourceResult) UnmarshalJSON(data []byte) error {
 
 	r.Contents = make([]ResourceContents, len(aux.Contents))
 	for i, content := range aux.Contents {
-		// Try to unmarshal content as TextResourceContents first
-		var textContent TextResourceContents
-		if err := pkg.JSONUnmarshal(content, &textContent); err == nil {
-			r.Contents[i] = textContent
-			continue
-		}
-
-		// Try to unmarshal content as BlobResourceContents
-		var blobContent BlobResourceContents
-		if err := pkg.JSONUnmarshal(content, &blobContent); err == nil {
-			r.Contents[i] = blobContent
-			continue
+		contents, err := UnmarshalResourceContents(content)
+		if err != nil {
+			return fmt.Errorf("contents at index %d: %w", i, err)
 		}
-
-		return fmt.Errorf("unknown content type at index %d", i)
+		r.Contents[i] = contents
 	}
-
 	return nil
 }
 
@@ -219,6 +218,46 @@ func (i *EmbeddedResource) GetType() string {
 	return "resource"
 }
 
+// UnmarshalJSON implements the json.Unmarshaler interface for EmbeddedResource
+func (i *EmbeddedResource) UnmarshalJSON(data []byte) error {
+	type Alias EmbeddedResource
+	aux := &struct {
+		Resource json.RawMessage `json:"resource"`
+		*Alias
+	}{
+		Alias: (*Alias)(i),
+	}
+	if err := pkg.JSONUnmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	resource, err := UnmarshalResourceContents(aux.Resource)
+	if err != nil {
+		return err
+	}
+	i.Resource = resource
+	return nil
+}
+
+// ResourceLink represents a reference to a resource that the client can read with resources/read,
+// returned in place of an EmbeddedResource when the resource body should not be inlined.
+type ResourceLink struct {
+	Type string `json:"type"` // Must be "resource_link"
+	Resource
+}
+
+// NewResourceLink creates a new ResourceLink
+func NewResourceLink(resource Resource) *ResourceLink {
+	return &ResourceLink{
+		Type:     "resource_link",
+		Resource: resource,
+	}
+}
+
+func (l *ResourceLink) GetType() string {
+	return "resource_link"
+}
+
 type ResourceContents interface {
 	GetURI() string
 	GetMimeType() string
@@ -254,17 +293,23 @@ func (b BlobResourceContents) GetMimeType() string {
 
 // SubscribeRequest represents a request to subscribe to resource updates
 type SubscribeRequest struct {
-	URI string `json:"uri"`
+	Meta map[string]interface{} `json:"_meta,omitempty"`
+	URI  string                 `json:"uri"`
 }
 
 // UnsubscribeRequest represents a request to unsubscribe from resource updates
 type UnsubscribeRequest struct {
-	URI string `json:"uri"`
+	Meta map[string]interface{} `json:"_meta,omitempty"`
+	URI  string                 `json:"uri"`
 }
 
-type SubscribeResult struct{}
+type SubscribeResult struct {
+	Meta map[string]interface{} `json:"_meta,omitempty"`
+}
 
-type UnsubscribeResult struct{}
+type UnsubscribeResult struct {
+	Meta map[string]interface{} `json:"_meta,omitempty"`
+}
 
 // ResourceListChangedNotification represents a notification that the resource list has changed
 type ResourceListChangedNotification struct {
@@ -273,7 +318,8 @@ type ResourceListChangedNotification struct {
 
 // ResourceUpdatedNotification represents a notification that a resource has been updated
 type ResourceUpdatedNotification struct {
-	URI string `json:"uri"`
+	Meta map[string]interface{} `json:"_meta,omitempty"`
+	URI  string                 `json:"uri"`
 }
 
 // NewListResourcesRequest creates a new list resources request
@@ -281,6 +327,12 @@ func NewListResourcesRequest() *ListResourcesRequest {
 	return &ListResourcesRequest{}
 }
 
+// WithCursor sets the cursor of the page to list, as returned in NextCursor by the previous page
+func (r *ListResourcesRequest) WithCursor(cursor string) *ListResourcesRequest {
+	r.Cursor = cursor
+	return r
+}
+
 // NewListResourcesResult creates a new list resources response
 func NewListResourcesResult(resources []Resource, nextCursor string) *ListResourcesResult {
 	return &ListResourcesResult{
@@ -294,6 +346,12 @@ func NewListResourceTemplatesRequest() *ListResourceTemplatesRequest {
 	return &ListResourceTemplatesRequest{}
 }
 
+// WithCursor sets the cursor of the page to list, as returned in NextCursor by the previous page
+func (r *ListResourceTemplatesRequest) WithCursor(cursor string) *ListResourceTemplatesRequest {
+	r.Cursor = cursor
+	return r
+}
+
 // NewListResourceTemplatesResult creates a new list resource templates response
 func NewListResourceTemplatesResult(templates []ResourceTemplate, nextCursor string) *ListResourceTemplatesResult {
 	return &ListResourceTemplatesResult{
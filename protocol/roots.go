@@ -1,11 +1,14 @@
 package protocol
 
 // ListRootsRequest represents a request to list root directories
-type ListRootsRequest struct{}
+type ListRootsRequest struct {
+	Meta map[string]interface{} `json:"_meta,omitempty"`
+}
 
 // ListRootsResult represents the response to a list roots request
 type ListRootsResult struct {
-	Roots []Root `json:"roots"`
+	Meta  map[string]interface{} `json:"_meta,omitempty"`
+	Roots []Root                 `json:"roots"`
 }
 
 // Root represents a root directory or file that the server can operate on
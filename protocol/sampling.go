@@ -2,13 +2,13 @@ package protocol
 
 import (
 	"encoding/json"
-	"fmt"
 
 	"github.com/ThinkInAIXYZ/go-mcp/pkg"
 )
 
 // CreateMessageRequest represents a request to create a message through sampling
 type CreateMessageRequest struct {
+	Meta             map[string]interface{} `json:"_meta,omitempty"`
 	Messages         []SamplingMessage      `json:"messages"`
 	MaxTokens        int                    `json:"maxTokens"`
 	Temperature      float64                `json:"temperature,omitempty"`
@@ -37,36 +37,21 @@ func (r *SamplingMessage) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
-	// Try to unmarshal content as TextContent first
-	var textContent *TextContent
-	if err := pkg.JSONUnmarshal(aux.Content, &textContent); err == nil {
-		r.Content = textContent
-		return nil
-	}
-
-	// Try to unmarshal content as ImageContent
-	var imageContent *ImageContent
-	if err := pkg.JSONUnmarshal(aux.Content, &imageContent); err == nil {
-		r.Content = imageContent
-		return nil
-	}
-
-	// Try to unmarshal content as AudioContent
-	var audioContent *AudioContent
-	if err := pkg.JSONUnmarshal(aux.Content, &audioContent); err == nil {
-		r.Content = audioContent
-		return nil
+	content, err := UnmarshalContent(aux.Content)
+	if err != nil {
+		return err
 	}
-
-	return fmt.Errorf("unknown content type, content=%s", aux.Content)
+	r.Content = content
+	return nil
 }
 
 // CreateMessageResult represents the response to a create message request
 type CreateMessageResult struct {
-	Content    Content `json:"content"`
-	Role       Role    `json:"role"`
-	Model      string  `json:"model"`
-	StopReason string  `json:"stopReason,omitempty"`
+	Meta       map[string]interface{} `json:"_meta,omitempty"`
+	Content    Content                `json:"content"`
+	Role       Role                   `json:"role"`
+	Model      string                 `json:"model"`
+	StopReason string                 `json:"stopReason,omitempty"`
 }
 
 // UnmarshalJSON implements the json.Unmarshaler interface for CreateMessageResult
@@ -82,28 +67,12 @@ func (r *CreateMessageResult) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
-	// Try to unmarshal content as TextContent first
-	var textContent *TextContent
-	if err := pkg.JSONUnmarshal(aux.Content, &textContent); err == nil {
-		r.Content = textContent
-		return nil
-	}
-
-	// Try to unmarshal content as ImageContent
-	var imageContent *ImageContent
-	if err := pkg.JSONUnmarshal(aux.Content, &imageContent); err == nil {
-		r.Content = imageContent
-		return nil
-	}
-
-	// Try to unmarshal content as AudioContent
-	var audioContent *AudioContent
-	if err := pkg.JSONUnmarshal(aux.Content, &audioContent); err == nil {
-		r.Content = audioContent
-		return nil
+	content, err := UnmarshalContent(aux.Content)
+	if err != nil {
+		return err
 	}
-
-	return fmt.Errorf("unknown content type, content=%s", aux.Content)
+	r.Content = content
+	return nil
 }
 
 // NewCreateMessageRequest creates a new create message request
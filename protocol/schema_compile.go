@@ -0,0 +1,412 @@
+package protocol
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ThinkInAIXYZ/go-mcp/pkg"
+)
+
+// CompiledSchema is a JSON Schema parsed once, that can then validate any number of values.
+//
+// The usual draft 2020-12 keywords are supported: type (including unions), enum, const,
+// minimum, maximum, exclusiveMinimum, exclusiveMaximum, multipleOf, minLength, maxLength, pattern, format,
+// properties, required, additionalProperties, minProperties, maxProperties, items, minItems, maxItems, uniqueItems,
+// allOf, anyOf, oneOf, not, and $ref to the schema itself or to its $defs (or definitions).
+// Unknown keywords are ignored, as are unknown formats.
+type CompiledSchema struct {
+	root *schemaNode
+}
+
+// schemaNode is one compiled (sub)schema
+type schemaNode struct {
+	// alwaysFalse is set by the false schema, which nothing matches
+	alwaysFalse bool
+
+	types []DataType
+
+	enum     []any
+	hasConst bool
+	constant any
+
+	minimum          *float64
+	maximum          *float64
+	exclusiveMinimum *float64
+	exclusiveMaximum *float64
+	multipleOf       *float64
+
+	minLength *int
+	maxLength *int
+	pattern   *regexp.Regexp
+	format    string
+
+	properties           map[string]*schemaNode
+	propertyNames        []string // sorted keys of properties, so that errors come out in a stable order
+	required             []string
+	additionalProperties *schemaNode
+	minProperties        *int
+	maxProperties        *int
+
+	items       *schemaNode
+	minItems    *int
+	maxItems    *int
+	uniqueItems bool
+
+	allOf []*schemaNode
+	anyOf []*schemaNode
+	oneOf []*schemaNode
+	not   *schemaNode
+
+	ref *schemaNode
+}
+
+// CompileSchema parses a JSON Schema. The schema is rejected if a keyword has a value of the wrong kind,
+// if it uses a type or a pattern the validator doesn't know, or if a $ref can't be resolved.
+// The draft 4 boolean exclusiveMinimum and exclusiveMaximum are rejected too, rather than being ignored.
+func CompileSchema(schema json.RawMessage) (*CompiledSchema, error) {
+	var raw any
+	if err := pkg.JSONUnmarshal(schema, &raw); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+
+	c := &schemaCompiler{document: raw, nodes: make(map[string]*schemaNode)}
+	root, err := c.compile("", raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	return &CompiledSchema{root: root}, nil
+}
+
+// compileProperty compiles a schema built with Property
+func compileProperty(schema *Property) (*CompiledSchema, error) {
+	raw, err := json.Marshal(scalarEnumOnly(schema))
+	if err != nil {
+		return nil, err
+	}
+	return CompileSchema(raw)
+}
+
+// scalarEnumOnly returns a copy of schema without the enums of its arrays and objects,
+// Property has always applied Enum to strings and numbers only.
+func scalarEnumOnly(schema *Property) *Property {
+	if schema == nil {
+		return nil
+	}
+
+	p := *schema
+	switch p.Type {
+	case String, Number, Integer:
+	default:
+		p.Enum = nil
+	}
+	p.Items = scalarEnumOnly(schema.Items)
+	p.AdditionalProperties = scalarEnumOnly(schema.AdditionalProperties)
+	p.Properties = scalarEnumOnlyMap(schema.Properties)
+	p.Defs = scalarEnumOnlyMap(schema.Defs)
+	return &p
+}
+
+func scalarEnumOnlyMap(schemas map[string]*Property) map[string]*Property {
+	if schemas == nil {
+		return nil
+	}
+	m := make(map[string]*Property, len(schemas))
+	for name, schema := range schemas {
+		m[name] = scalarEnumOnly(schema)
+	}
+	return m
+}
+
+type schemaCompiler struct {
+	document any
+	// nodes holds the compiled schemas by JSON pointer, a schema is added before its subschemas are compiled,
+	// so that a recursive $ref resolves to the node being compiled instead of recursing forever.
+	nodes map[string]*schemaNode
+}
+
+func (c *schemaCompiler) compile(pointer string, raw any) (*schemaNode, error) {
+	if node, ok := c.nodes[pointer]; ok {
+		return node, nil
+	}
+
+	node := &schemaNode{}
+	c.nodes[pointer] = node
+
+	switch schema := raw.(type) {
+	case bool:
+		node.alwaysFalse = !schema
+		return node, nil
+	case map[string]any:
+		if err := c.compileKeywords(node, pointer, schema); err != nil {
+			return nil, err
+		}
+		return node, nil
+	default:
+		return nil, &ValidationError{Path: pointer, Reason: fmt.Sprintf("schema must be an object or a boolean, got %s", jsonTypeOf(raw))}
+	}
+}
+
+func (c *schemaCompiler) compileKeywords(node *schemaNode, pointer string, schema map[string]any) error {
+	var err error
+
+	if node.types, err = compileTypes(pointer, schema["type"]); err != nil {
+		return err
+	}
+
+	if enum, ok := schema["enum"]; ok {
+		values, ok := enum.([]any)
+		if !ok {
+			return keywordError(pointer, "enum", "an array")
+		}
+		node.enum = coerceNumericEnum(node.types, values)
+	}
+	if constant, ok := schema["const"]; ok {
+		node.hasConst, node.constant = true, constant
+	}
+
+	for keyword, target := range map[string]**float64{
+		"minimum":          &node.minimum,
+		"maximum":          &node.maximum,
+		"exclusiveMinimum": &node.exclusiveMinimum,
+		"exclusiveMaximum": &node.exclusiveMaximum,
+		"multipleOf":       &node.multipleOf,
+	} {
+		value, ok := schema[keyword]
+		if !ok {
+			continue
+		}
+		if _, isBool := value.(bool); isBool && strings.HasPrefix(keyword, "exclusive") {
+			// the draft 4 form, a boolean modifier of minimum and maximum, would otherwise be silently ignored
+			return keywordError(pointer, keyword, "a number, the draft 4 boolean form is not supported")
+		}
+		number, ok := toNumber(value)
+		if !ok {
+			return keywordError(pointer, keyword, "a number")
+		}
+		*target = &number
+	}
+
+	for keyword, target := range map[string]**int{
+		"minLength":     &node.minLength,
+		"maxLength":     &node.maxLength,
+		"minProperties": &node.minProperties,
+		"maxProperties": &node.maxProperties,
+		"minItems":      &node.minItems,
+		"maxItems":      &node.maxItems,
+	} {
+		value, ok := schema[keyword]
+		if !ok {
+			continue
+		}
+		number, ok := toNumber(value)
+		if !ok || number < 0 || number != float64(int(number)) {
+			return keywordError(pointer, keyword, "a non-negative integer")
+		}
+		n := int(number)
+		*target = &n
+	}
+
+	if pattern, ok := schema["pattern"]; ok {
+		s, ok := pattern.(string)
+		if !ok {
+			return keywordError(pointer, "pattern", "a string")
+		}
+		if node.pattern, err = regexp.Compile(s); err != nil {
+			return &ValidationError{Path: pointer + "/pattern", Reason: err.Error()}
+		}
+	}
+	if format, ok := schema["format"]; ok {
+		if node.format, ok = format.(string); !ok {
+			return keywordError(pointer, "format", "a string")
+		}
+	}
+
+	if properties, ok := schema["properties"]; ok {
+		m, ok := properties.(map[string]any)
+		if !ok {
+			return keywordError(pointer, "properties", "an object")
+		}
+		node.properties = make(map[string]*schemaNode, len(m))
+		for name, property := range m {
+			if node.properties[name], err = c.compile(pointer+"/properties/"+escapePointer(name), property); err != nil {
+				return err
+			}
+			node.propertyNames = append(node.propertyNames, name)
+		}
+		sort.Strings(node.propertyNames)
+	}
+	if required, ok := schema["required"]; ok {
+		if node.required, ok = toStrings(required); !ok {
+			return keywordError(pointer, "required", "an array of strings")
+		}
+	}
+	if additional, ok := schema["additionalProperties"]; ok {
+		if node.additionalProperties, err = c.compile(pointer+"/additionalProperties", additional); err != nil {
+			return err
+		}
+	}
+
+	if items, ok := schema["items"]; ok {
+		if node.items, err = c.compile(pointer+"/items", items); err != nil {
+			return err
+		}
+	}
+	if unique, ok := schema["uniqueItems"]; ok {
+		if node.uniqueItems, ok = unique.(bool); !ok {
+			return keywordError(pointer, "uniqueItems", "a boolean")
+		}
+	}
+
+	for keyword, target := range map[string]*[]*schemaNode{"allOf": &node.allOf, "anyOf": &node.anyOf, "oneOf": &node.oneOf} {
+		value, ok := schema[keyword]
+		if !ok {
+			continue
+		}
+		subschemas, ok := value.([]any)
+		if !ok || len(subschemas) == 0 {
+			return keywordError(pointer, keyword, "a non-empty array")
+		}
+		for i, subschema := range subschemas {
+			compiled, err := c.compile(pointer+"/"+keyword+"/"+strconv.Itoa(i), subschema)
+			if err != nil {
+				return err
+			}
+			*target = append(*target, compiled)
+		}
+	}
+	if not, ok := schema["not"]; ok {
+		if node.not, err = c.compile(pointer+"/not", not); err != nil {
+			return err
+		}
+	}
+
+	if ref, ok := schema["$ref"]; ok {
+		s, ok := ref.(string)
+		if !ok {
+			return keywordError(pointer, "$ref", "a string")
+		}
+		if node.ref, err = c.resolve(pointer, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolve compiles the schema a $ref points to, only references inside the document are supported
+func (c *schemaCompiler) resolve(pointer, ref string) (*schemaNode, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, &ValidationError{Path: pointer + "/$ref", Reason: fmt.Sprintf("unsupported reference %q, only references inside the schema are supported", ref)}
+	}
+
+	target := strings.TrimPrefix(ref, "#")
+	raw := c.document
+	if target != "" {
+		for _, token := range strings.Split(strings.TrimPrefix(target, "/"), "/") {
+			token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+			var ok bool
+			switch v := raw.(type) {
+			case map[string]any:
+				raw, ok = v[token]
+			case []any:
+				var i int
+				if i, ok = parseIndex(token); ok && i < len(v) {
+					raw = v[i]
+				} else {
+					ok = false
+				}
+			}
+			if !ok {
+				return nil, &ValidationError{Path: pointer + "/$ref", Reason: fmt.Sprintf("reference %q not found", ref)}
+			}
+		}
+	}
+	return c.compile(target, raw)
+}
+
+func compileTypes(pointer string, value any) ([]DataType, error) {
+	var names []string
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		if v == "" {
+			// Property marshals an unset type as an empty string
+			return nil, nil
+		}
+		names = []string{v}
+	default:
+		var ok bool
+		if names, ok = toStrings(v); !ok || len(names) == 0 {
+			return nil, keywordError(pointer, "type", "a string or a non-empty array of strings")
+		}
+	}
+
+	types := make([]DataType, 0, len(names))
+	for _, name := range names {
+		switch t := DataType(name); t {
+		case ObjectT, Array, String, Number, Integer, Boolean, Null:
+			types = append(types, t)
+		default:
+			return nil, &ValidationError{Path: pointer + "/type", Reason: fmt.Sprintf("unknown type %q", name)}
+		}
+	}
+	return types, nil
+}
+
+// coerceNumericEnum turns the enum values of numeric schemas that are written as strings into numbers,
+// Property keeps enum values as strings whatever the type, e.g. enum:"1,2,3" on an int field.
+func coerceNumericEnum(types []DataType, values []any) []any {
+	for _, t := range types {
+		if t != Number && t != Integer {
+			return values
+		}
+	}
+	if len(types) == 0 {
+		return values
+	}
+
+	coerced := make([]any, len(values))
+	for i, value := range values {
+		coerced[i] = value
+		if s, ok := value.(string); ok {
+			if number, err := strconv.ParseFloat(s, 64); err == nil {
+				coerced[i] = number
+			}
+		}
+	}
+	return coerced
+}
+
+func keywordError(pointer, keyword, expected string) error {
+	return &ValidationError{Path: pointer + "/" + keyword, Reason: fmt.Sprintf("%s must be %s", keyword, expected)}
+}
+
+func toStrings(value any) ([]string, bool) {
+	values, ok := value.([]any)
+	if !ok {
+		return nil, false
+	}
+	strs := make([]string, 0, len(values))
+	for _, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			return nil, false
+		}
+		strs = append(strs, s)
+	}
+	return strs, true
+}
+
+func parseIndex(token string) (int, bool) {
+	i, err := strconv.Atoi(token)
+	return i, err == nil && i >= 0
+}
+
+// escapePointer escapes a JSON pointer reference token, see RFC 6901
+func escapePointer(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
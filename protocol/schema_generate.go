@@ -1,10 +1,14 @@
 package protocol
 
 import (
+	"encoding/json"
 	"fmt"
 	"reflect"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/ThinkInAIXYZ/go-mcp/pkg"
 )
@@ -22,7 +26,7 @@ const (
 )
 
 type Property struct {
-	Type DataType `json:"type"`
+	Type DataType `json:"type,omitempty"`
 	// Description is the description of the schema.
 	Description string `json:"description,omitempty"`
 	// Items specifies which data type an array contains, if the schema type is Array.
@@ -31,6 +35,20 @@ type Property struct {
 	Properties map[string]*Property `json:"properties,omitempty"`
 	Required   []string             `json:"required,omitempty"`
 	Enum       []string             `json:"enum,omitempty"`
+	// AdditionalProperties describes the values of the properties not listed in Properties, e.g. of a map.
+	AdditionalProperties *Property `json:"additionalProperties,omitempty"`
+
+	Minimum  *float64      `json:"minimum,omitempty"`
+	Maximum  *float64      `json:"maximum,omitempty"`
+	Pattern  string        `json:"pattern,omitempty"`
+	Format   string        `json:"format,omitempty"`
+	MinItems *int          `json:"minItems,omitempty"`
+	Default  interface{}   `json:"default,omitempty"`
+	Examples []interface{} `json:"examples,omitempty"`
+
+	// Ref points to the schema to use instead, e.g. "#/$defs/Node", Defs holds the schemas referenced that way.
+	Ref  string               `json:"$ref,omitempty"`
+	Defs map[string]*Property `json:"$defs,omitempty"`
 }
 
 var schemaCache = pkg.SyncMap[*InputSchema]{}
@@ -51,13 +69,17 @@ func generateSchemaFromReqStruct(v any) (*InputSchema, error) {
 
 	schema := &InputSchema{Type: Object}
 
-	property, err := reflectSchemaByObject(t)
+	g := newSchemaGenerator(t)
+	property, err := g.reflectSchemaByObject(t)
 	if err != nil {
 		return nil, err
 	}
 
 	schema.Properties = property.Properties
 	schema.Required = property.Required
+	if len(g.defs) > 0 {
+		schema.Defs = g.defs
+	}
 
 	schemaCache.Store(typeUID, schema)
 	return schema, nil
@@ -71,91 +93,225 @@ func getTypeUUID(t reflect.Type) string {
 	return t.String()
 }
 
-func reflectSchemaByObject(t reflect.Type) (*Property, error) {
+// schemaGenerator generates the schema of a request struct. The named struct types it meets are generated once,
+// into defs, and referenced with $ref everywhere they are used, which also ends the recursion of self-referential types.
+type schemaGenerator struct {
+	defs map[string]*Property
+	// refs holds the $ref of every named struct type met so far, the root type is referenced as the whole schema
+	refs map[reflect.Type]string
+}
+
+func newSchemaGenerator(root reflect.Type) *schemaGenerator {
+	return &schemaGenerator{
+		defs: make(map[string]*Property),
+		refs: map[reflect.Type]string{root: "#"},
+	}
+}
+
+// reflectSchemaByRef returns a reference to the definition of the named struct type t, generating it the first time
+func (g *schemaGenerator) reflectSchemaByRef(t reflect.Type) (*Property, error) {
+	if ref, ok := g.refs[t]; ok {
+		return &Property{Ref: ref}, nil
+	}
+
+	name := defName(t)
+	if _, taken := g.defs[name]; taken {
+		// another type with the same name, from another package
+		name = defName(t, t.PkgPath())
+	}
+	ref := "#/$defs/" + escapePointer(name)
+
+	// reserve the definition before generating it, so that the references met on the way resolve to it
+	g.refs[t] = ref
+	g.defs[name] = &Property{}
+
+	object, err := g.reflectSchemaByObject(t)
+	if err != nil {
+		return nil, err
+	}
+	g.defs[name] = object
+	return &Property{Ref: ref}, nil
+}
+
+var defNameReplacer = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// defName names the definition of t, qualifiers such as the package path are prepended when given
+func defName(t reflect.Type, qualifiers ...string) string {
+	name := strings.Join(append(qualifiers, t.Name()), ".")
+	return strings.Trim(defNameReplacer.ReplaceAllString(name, "_"), "_")
+}
+
+func (g *schemaGenerator) reflectSchemaByObject(t reflect.Type) (*Property, error) {
+	fields, err := structFieldsOf(t)
+	if err != nil {
+		return nil, err
+	}
+
+	properties := make(map[string]*Property, len(fields))
+	requiredFields := make([]string, 0)
+	for _, field := range fields {
+		item, err := g.reflectSchemaByType(field.Type)
+		if err != nil {
+			return nil, err
+		}
+
+		if field.Description != "" {
+			item.Description = field.Description
+		}
+		if err = applyConstraintTags(item, field.StructField); err != nil {
+			return nil, fmt.Errorf("field %v: %w", field.Name, err)
+		}
+		if field.Enum != nil {
+			item.Enum = field.Enum
+		}
+		properties[field.Name] = item
+
+		if field.Required {
+			requiredFields = append(requiredFields, field.Name)
+		}
+	}
+
+	property := &Property{
+		Type:       ObjectT,
+		Properties: properties,
+		Required:   requiredFields,
+	}
+	return property, nil
+}
+
+// structField is a field of a struct as it is encoded in JSON, described by its tags:
+// the json tag names it and omitempty makes it optional, unless the required tag says otherwise,
+// the description and enum tags give its description and allowed values.
+type structField struct {
+	reflect.StructField
+	Name        string
+	Required    bool
+	Description string
+	Enum        []string
+}
+
+// structFieldsOf returns the JSON fields of the struct type t in declaration order. The fields of anonymous embedded structs
+// are flattened after them, in name order, and are shadowed by the fields of t itself like encoding/json does.
+// The Index of every field is relative to t.
+func structFieldsOf(t reflect.Type) ([]structField, error) {
 	var (
-		properties     = make(map[string]*Property)
-		requiredFields = make([]string, 0)
-		enumValues     = make([]string, 0)
+		fields = make([]structField, 0, t.NumField())
+		names  = make(map[string]bool, t.NumField())
+
+		embeddedFields = make(map[string]structField)
 	)
 
 	for i := 0; i < t.NumField(); i++ {
 		field := t.Field(i)
-		if !field.IsExported() {
-			continue
-		}
 
 		jsonTag := field.Tag.Get("json")
 		if jsonTag == "-" {
 			continue
 		}
-		required := true
-		if jsonTag == "" {
-			jsonTag = field.Name
-		}
-		if strings.HasSuffix(jsonTag, ",omitempty") {
-			jsonTag = strings.TrimSuffix(jsonTag, ",omitempty")
-			required = false
-		}
+		name, options, _ := strings.Cut(jsonTag, ",")
 
-		item, err := reflectSchemaByType(field.Type)
-		if err != nil {
-			return nil, err
+		if field.Anonymous && name == "" && isStructType(field.Type) {
+			embedded, err := structFieldsOf(indirectType(field.Type))
+			if err != nil {
+				return nil, err
+			}
+			for _, f := range embedded {
+				f.Index = append(append([]int{}, field.Index...), f.Index...)
+				embeddedFields[f.Name] = f
+			}
+			continue
 		}
 
-		if description := field.Tag.Get("description"); description != "" {
-			item.Description = description
+		if !field.IsExported() {
+			continue
 		}
-		properties[jsonTag] = item
 
+		if name == "" {
+			name = field.Name
+		}
+		required := !strings.Contains(","+options+",", ",omitempty,")
 		if s := field.Tag.Get("required"); s != "" {
-			required, err = strconv.ParseBool(s)
-			if err != nil {
-				return nil, fmt.Errorf("invalid required field %v: %v", jsonTag, err)
+			var err error
+			if required, err = strconv.ParseBool(s); err != nil {
+				return nil, fmt.Errorf("invalid required field %v: %v", name, err)
 			}
 		}
-		if required {
-			requiredFields = append(requiredFields, jsonTag)
+
+		enum, err := parseEnumTag(field)
+		if err != nil {
+			return nil, err
 		}
 
-		if v := field.Tag.Get("enum"); v != "" {
-			enumValues = strings.Split(v, ",")
-			for i := range enumValues {
-				enumValues[i] = strings.TrimSpace(enumValues[i])
-			}
+		fields = append(fields, structField{
+			StructField: field,
+			Name:        name,
+			Required:    required,
+			Description: field.Tag.Get("description"),
+			Enum:        enum,
+		})
+		names[name] = true
+	}
 
-			// Check if enum values are consistent with the field type
-			for _, value := range enumValues {
-				switch field.Type.Kind() {
-				case reflect.String:
-					// No additional processing required for string type
-				case reflect.Int, reflect.Int64:
-					if _, err := strconv.Atoi(value); err != nil {
-						return nil, fmt.Errorf("enum value %q is not compatible with type %v", value, field.Type)
-					}
-				case reflect.Float64:
-					if _, err := strconv.ParseFloat(value, 64); err != nil {
-						return nil, fmt.Errorf("enum value %q is not compatible with type %v", value, field.Type)
-					}
-				default:
-					return nil, fmt.Errorf("unsupported type %v for enum validation", field.Type)
-				}
-			}
-			item.Enum = enumValues
+	embeddedNames := make([]string, 0, len(embeddedFields))
+	for name := range embeddedFields {
+		embeddedNames = append(embeddedNames, name)
+	}
+	sort.Strings(embeddedNames)
+	for _, name := range embeddedNames {
+		if !names[name] {
+			fields = append(fields, embeddedFields[name])
 		}
 	}
+	return fields, nil
+}
 
-	property := &Property{
-		Type:       ObjectT,
-		Properties: properties,
-		Required:   requiredFields,
-		Enum:       enumValues,
+// parseEnumTag returns the values of the enum tag of field, checking that they are consistent with the field type
+func parseEnumTag(field reflect.StructField) ([]string, error) {
+	v := field.Tag.Get("enum")
+	if v == "" {
+		return nil, nil
 	}
-	return property, nil
+
+	enumValues := strings.Split(v, ",")
+	for i := range enumValues {
+		enumValues[i] = strings.TrimSpace(enumValues[i])
+	}
+
+	for _, value := range enumValues {
+		switch field.Type.Kind() {
+		case reflect.String:
+			// No additional processing required for string type
+		case reflect.Int, reflect.Int64:
+			if _, err := strconv.Atoi(value); err != nil {
+				return nil, fmt.Errorf("enum value %q is not compatible with type %v", value, field.Type)
+			}
+		case reflect.Float64:
+			if _, err := strconv.ParseFloat(value, 64); err != nil {
+				return nil, fmt.Errorf("enum value %q is not compatible with type %v", value, field.Type)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported type %v for enum validation", field.Type)
+		}
+	}
+	return enumValues, nil
 }
 
-func reflectSchemaByType(t reflect.Type) (*Property, error) {
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+func (g *schemaGenerator) reflectSchemaByType(t reflect.Type) (*Property, error) {
 	s := &Property{}
 
+	switch t {
+	case timeType:
+		return &Property{Type: String, Format: "date-time"}, nil
+	case rawMessageType:
+		// any JSON value
+		return s, nil
+	}
+
 	switch t.Kind() {
 	case reflect.String:
 		s.Type = String
@@ -168,29 +324,134 @@ func reflectSchemaByType(t reflect.Type) (*Property, error) {
 		s.Type = Boolean
 	case reflect.Slice, reflect.Array:
 		s.Type = Array
-		items, err := reflectSchemaByType(t.Elem())
+		items, err := g.reflectSchemaByType(t.Elem())
 		if err != nil {
 			return nil, err
 		}
 		s.Items = items
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("unsupported map key type: %s, only string keys can be JSON object keys", t.Key())
+		}
+		values, err := g.reflectSchemaByType(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		s.Type = ObjectT
+		s.AdditionalProperties = values
 	case reflect.Struct:
-		object, err := reflectSchemaByObject(t)
+		if t.Name() != "" {
+			return g.reflectSchemaByRef(t)
+		}
+		object, err := g.reflectSchemaByObject(t)
 		if err != nil {
 			return nil, err
 		}
 		object.Type = ObjectT
 		s = object
 	case reflect.Ptr:
-		p, err := reflectSchemaByType(t.Elem())
+		p, err := g.reflectSchemaByType(t.Elem())
 		if err != nil {
 			return nil, err
 		}
 		s = p
+	case reflect.Interface:
+		// any JSON value
 	case reflect.Invalid, reflect.Uintptr, reflect.Complex64, reflect.Complex128,
-		reflect.Chan, reflect.Func, reflect.Interface, reflect.Map,
-		reflect.UnsafePointer:
+		reflect.Chan, reflect.Func, reflect.UnsafePointer:
 		return nil, fmt.Errorf("unsupported type: %s", t.Kind().String())
 	default:
 	}
 	return s, nil
 }
+
+// applyConstraintTags sets the constraints given by the minimum, maximum, pattern, format, default, examples
+// and minItems tags of field.
+func applyConstraintTags(property *Property, field reflect.StructField) error {
+	t := indirectType(field.Type)
+
+	for tag, target := range map[string]**float64{"minimum": &property.Minimum, "maximum": &property.Maximum} {
+		v := field.Tag.Get(tag)
+		if v == "" {
+			continue
+		}
+		if property.Type != Integer && property.Type != Number {
+			return fmt.Errorf("%s tag is not compatible with type %v", tag, field.Type)
+		}
+		number, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return fmt.Errorf("invalid %s %q: %v", tag, v, err)
+		}
+		*target = &number
+	}
+
+	if v := field.Tag.Get("pattern"); v != "" {
+		if property.Type != String {
+			return fmt.Errorf("pattern tag is not compatible with type %v", field.Type)
+		}
+		if _, err := regexp.Compile(v); err != nil {
+			return fmt.Errorf("invalid pattern %q: %v", v, err)
+		}
+		property.Pattern = v
+	}
+
+	if v := field.Tag.Get("format"); v != "" {
+		property.Format = v
+	}
+
+	if v := field.Tag.Get("minItems"); v != "" {
+		if property.Type != Array {
+			return fmt.Errorf("minItems tag is not compatible with type %v", field.Type)
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid minItems %q, it must be a non-negative integer", v)
+		}
+		property.MinItems = &n
+	}
+
+	if v, ok := field.Tag.Lookup("default"); ok {
+		value, err := parseTagValue(t, v)
+		if err != nil {
+			return fmt.Errorf("invalid default %q: %v", v, err)
+		}
+		property.Default = value
+	}
+
+	if v := field.Tag.Get("examples"); v != "" {
+		for _, example := range strings.Split(v, ",") {
+			value, err := parseTagValue(t, strings.TrimSpace(example))
+			if err != nil {
+				return fmt.Errorf("invalid example %q: %v", example, err)
+			}
+			property.Examples = append(property.Examples, value)
+		}
+	}
+	return nil
+}
+
+// parseTagValue turns the text of a default or examples tag into a value of type t,
+// strings are taken as is, other values are written in JSON.
+func parseTagValue(t reflect.Type, v string) (interface{}, error) {
+	if t.Kind() == reflect.String || t == timeType {
+		return v, nil
+	}
+
+	value := reflect.New(t)
+	if err := pkg.JSONUnmarshal([]byte(v), value.Interface()); err != nil {
+		return nil, err
+	}
+	return value.Elem().Interface(), nil
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+func isStructType(t reflect.Type) bool {
+	t = indirectType(t)
+	return t.Kind() == reflect.Struct && t != timeType
+}
@@ -1,8 +1,12 @@
 package protocol
 
 import (
+	"encoding/json"
+	"errors"
+	"reflect"
 	"sort"
 	"testing"
+	"time"
 )
 
 func TestGenerateSchemaFromReqStruct(t *testing.T) {
@@ -383,3 +387,181 @@ func compareProperty(a, b *Property) bool {
 
 	return true
 }
+
+type testBaseReq struct {
+	RequestID string `json:"request_id" description:"request id"`
+	Trace     bool   `json:"trace,omitempty"`
+}
+
+type testRichReq struct {
+	testBaseReq
+	Trace bool `json:"trace" description:"shadows the embedded field"`
+
+	Labels   map[string]string          `json:"labels,omitempty"`
+	Scores   map[string][]float64       `json:"scores,omitempty"`
+	Since    time.Time                  `json:"since"`
+	Payload  json.RawMessage            `json:"payload,omitempty"`
+	Anything interface{}                `json:"anything,omitempty"`
+	Limit    int                        `json:"limit,omitempty" minimum:"1" maximum:"100" default:"10" examples:"10,50"`
+	Email    string                     `json:"email" format:"email" pattern:"@example\\.com$"`
+	Tags     []string                   `json:"tags" minItems:"1"`
+	Ratio    *float64                   `json:"ratio,omitempty" default:"0.5"`
+	Nested   map[string]json.RawMessage `json:"nested,omitempty"`
+}
+
+func TestGenerateSchemaRichTypes(t *testing.T) {
+	schema, err := generateSchemaFromReqStruct(testRichReq{})
+	if err != nil {
+		t.Fatalf("generateSchemaFromReqStruct: %+v", err)
+	}
+
+	got, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatalf("json Marshal: %+v", err)
+	}
+	expected := `{
+		"type": "object",
+		"properties": {
+			"request_id": {"type": "string", "description": "request id"},
+			"trace": {"type": "boolean", "description": "shadows the embedded field"},
+			"labels": {"type": "object", "additionalProperties": {"type": "string"}},
+			"scores": {"type": "object", "additionalProperties": {"type": "array", "items": {"type": "number"}}},
+			"since": {"type": "string", "format": "date-time"},
+			"payload": {},
+			"anything": {},
+			"limit": {"type": "integer", "minimum": 1, "maximum": 100, "default": 10, "examples": [10, 50]},
+			"email": {"type": "string", "format": "email", "pattern": "@example\\.com$"},
+			"tags": {"type": "array", "items": {"type": "string"}, "minItems": 1},
+			"ratio": {"type": "number", "default": 0.5},
+			"nested": {"type": "object", "additionalProperties": {}}
+		},
+		"required": ["trace", "since", "email", "tags", "request_id"]
+	}`
+	var gotValue, expectedValue interface{}
+	if err = json.Unmarshal(got, &gotValue); err != nil {
+		t.Fatalf("json Unmarshal: %+v", err)
+	}
+	if err = json.Unmarshal([]byte(expected), &expectedValue); err != nil {
+		t.Fatalf("json Unmarshal: %+v", err)
+	}
+	if !reflect.DeepEqual(gotValue, expectedValue) {
+		t.Fatalf("schema not as expected.\ngot  = %s\nwant = %s", got, expected)
+	}
+
+	compiled, err := CompileSchema(got)
+	if err != nil {
+		t.Fatalf("CompileSchema: %+v", err)
+	}
+	testValidationErrors(t, compiled, `{"request_id": "1", "trace": true, "since": "2025-06-18T10:00:00Z",
+		"email": "a@example.com", "tags": ["x"], "labels": {"k": "v"}, "payload": [1, {}], "limit": 10}`, nil)
+	testValidationErrors(t, compiled, `{"request_id": "1", "trace": true, "since": "yesterday",
+		"email": "a@example.com", "tags": [], "labels": {"k": 1}, "limit": 0}`, []string{"/labels/k", "/limit", "/since", "/tags"})
+
+	for _, v := range []interface{}{
+		struct {
+			M map[int]string `json:"m"`
+		}{},
+		struct {
+			S string `json:"s" minimum:"1"`
+		}{},
+		struct {
+			N int `json:"n" pattern:"^a"`
+		}{},
+		struct {
+			N int `json:"n" default:"abc"`
+		}{},
+		struct {
+			N int `json:"n" minItems:"1"`
+		}{},
+	} {
+		if _, err := generateSchemaFromReqStruct(v); err == nil {
+			t.Errorf("generateSchemaFromReqStruct(%T) should fail", v)
+		}
+	}
+}
+
+type testTreeNode struct {
+	Value    string          `json:"value"`
+	Children []*testTreeNode `json:"children,omitempty"`
+	Meta     *testNodeMeta   `json:"meta,omitempty"`
+}
+
+type testNodeMeta struct {
+	Author string `json:"author"`
+}
+
+type testTreeReq struct {
+	Root    testTreeNode  `json:"root"`
+	Parent  *testTreeReq  `json:"parent,omitempty"`
+	Comment *testNodeMeta `json:"comment,omitempty" description:"comment author"`
+}
+
+func TestGenerateSchemaWithDefs(t *testing.T) {
+	schema, err := generateSchemaFromReqStruct(testTreeReq{})
+	if err != nil {
+		t.Fatalf("generateSchemaFromReqStruct: %+v", err)
+	}
+
+	got, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatalf("json Marshal: %+v", err)
+	}
+	expected := `{
+		"type": "object",
+		"properties": {
+			"root": {"$ref": "#/$defs/testTreeNode"},
+			"parent": {"$ref": "#"},
+			"comment": {"$ref": "#/$defs/testNodeMeta", "description": "comment author"}
+		},
+		"required": ["root"],
+		"$defs": {
+			"testTreeNode": {
+				"type": "object",
+				"properties": {
+					"value": {"type": "string"},
+					"children": {"type": "array", "items": {"$ref": "#/$defs/testTreeNode"}},
+					"meta": {"$ref": "#/$defs/testNodeMeta"}
+				},
+				"required": ["value"]
+			},
+			"testNodeMeta": {
+				"type": "object",
+				"properties": {"author": {"type": "string"}},
+				"required": ["author"]
+			}
+		}
+	}`
+	var gotValue, expectedValue interface{}
+	if err = json.Unmarshal(got, &gotValue); err != nil {
+		t.Fatalf("json Unmarshal: %+v", err)
+	}
+	if err = json.Unmarshal([]byte(expected), &expectedValue); err != nil {
+		t.Fatalf("json Unmarshal: %+v", err)
+	}
+	if !reflect.DeepEqual(gotValue, expectedValue) {
+		t.Fatalf("schema not as expected.\ngot  = %s\nwant = %s", got, expected)
+	}
+
+	var req testTreeReq
+	if err = VerifyAndUnmarshal(json.RawMessage(`{"root": {"value": "a", "children": [{"value": "b", "children": [{"value": "c"}]}]},
+		"parent": {"root": {"value": "p", "meta": {"author": "x"}}}}`), &req); err != nil {
+		t.Fatalf("VerifyAndUnmarshal: %+v", err)
+	}
+	if req.Root.Children[0].Children[0].Value != "c" || req.Parent.Root.Meta.Author != "x" {
+		t.Fatalf("request not decoded as expected: %+v", req)
+	}
+
+	err = VerifyAndUnmarshal(json.RawMessage(`{"root": {"value": "a", "children": [{"value": "b", "children": [{"value": 1}]}]},
+		"parent": {"root": {"value": "p", "meta": {}}}}`), &req)
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("VerifyAndUnmarshal = %v, want ValidationErrors", err)
+	}
+	paths := make([]string, 0, len(errs))
+	for _, e := range errs {
+		paths = append(paths, e.Path)
+	}
+	if expected := []string{"/parent/root/meta/author", "/root/children/0/children/0/value"}; !reflect.DeepEqual(paths, expected) {
+		t.Fatalf("error paths not as expected.\ngot  = %q\nwant = %q", paths, expected)
+	}
+}
@@ -2,10 +2,18 @@ package protocol
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
+	"math"
+	"net"
+	"net/mail"
+	"net/url"
 	"reflect"
+	"regexp"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/ThinkInAIXYZ/go-mcp/pkg"
 )
@@ -24,141 +32,456 @@ func VerifyAndUnmarshal(content json.RawMessage, v any) error {
 	}
 
 	typeUID := getTypeUUID(t)
-	schema, ok := schemaCache.Load(typeUID)
+	compiled, ok := compiledSchemaCache.Load(typeUID)
 	if !ok {
-		return fmt.Errorf("schema has not been generated，unable to verify: plz use func `pkg.JSONUnmarshal` instead")
+		schema, ok := schemaCache.Load(typeUID)
+		if !ok {
+			return fmt.Errorf("schema has not been generated，unable to verify: plz use func `pkg.JSONUnmarshal` instead")
+		}
+
+		var err error
+		if compiled, err = compileProperty(&Property{
+			Type:       ObjectT,
+			Properties: schema.Properties,
+			Required:   schema.Required,
+			Defs:       schema.Defs,
+		}); err != nil {
+			return err
+		}
+		compiledSchemaCache.Store(typeUID, compiled)
 	}
 
-	return verifySchemaAndUnmarshal(Property{
-		Type:       ObjectT,
-		Properties: schema.Properties,
-		Required:   schema.Required,
-	}, content, v)
+	return verifyCompiledSchemaAndUnmarshal(compiled, content, v)
 }
 
-func verifySchemaAndUnmarshal(schema Property, content []byte, v any) error {
+// compiledSchemaCache holds the schemas of schemaCache once compiled by VerifyAndUnmarshal
+var compiledSchemaCache = pkg.SyncMap[*CompiledSchema]{}
+
+func verifyCompiledSchemaAndUnmarshal(schema *CompiledSchema, content []byte, v any) error {
 	var data any
 	err := pkg.JSONUnmarshal(content, &data)
 	if err != nil {
 		return err
 	}
-	if !validate(schema, data) {
-		return errors.New("data validation failed against the provided schema")
+	if err = schema.Validate(data); err != nil {
+		return fmt.Errorf("data validation failed against the provided schema: %w", err)
 	}
 	return pkg.JSONUnmarshal(content, &v)
 }
 
-func validate(schema Property, data any) bool {
-	switch schema.Type {
-	case ObjectT:
-		return validateObject(schema, data)
-	case Array:
-		return validateArray(schema, data)
-	case String:
-		str, ok := data.(string)
-		if ok {
-			return validateEnumProperty[string](str, schema.Enum, func(value string, enumValue string) bool {
-				return value == enumValue
-			})
-		}
-		return false
-	case Number: // float64 and int
-		if num, ok := data.(float64); ok {
-			return validateEnumProperty[float64](num, schema.Enum, func(value float64, enumValue string) bool {
-				if enumNum, err := strconv.ParseFloat(enumValue, 64); err == nil && value == enumNum {
-					return true
-				}
-				return false
-			})
+// ValidationError reports a value that doesn't match its schema
+type ValidationError struct {
+	// Path is the JSON pointer of the offending value, or of the missing or disallowed property, empty for the whole value
+	Path   string
+	Reason string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Path == "" {
+		return e.Reason
+	}
+	return e.Path + ": " + e.Reason
+}
+
+// ValidationErrors lists every mismatch found between a value and its schema
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	reasons := make([]string, 0, len(e))
+	for _, err := range e {
+		reasons = append(reasons, err.Error())
+	}
+	return strings.Join(reasons, "; ")
+}
+
+// Validate checks data, as decoded by encoding/json, against the schema.
+// The returned error is a ValidationErrors listing every mismatch.
+func (s *CompiledSchema) Validate(data any) error {
+	var errs ValidationErrors
+	s.root.validate(data, "", &errs)
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// ValidateJSON checks the JSON encoded content against the schema
+func (s *CompiledSchema) ValidateJSON(content json.RawMessage) error {
+	var data any
+	if err := pkg.JSONUnmarshal(content, &data); err != nil {
+		return err
+	}
+	return s.Validate(data)
+}
+
+// validate appends to errs every mismatch between data and the schema, path is the JSON pointer of data
+func (n *schemaNode) validate(data any, path string, errs *ValidationErrors) {
+	report := func(format string, args ...any) {
+		*errs = append(*errs, &ValidationError{Path: path, Reason: fmt.Sprintf(format, args...)})
+	}
+
+	if n.alwaysFalse {
+		report("no value is allowed here")
+		return
+	}
+
+	if n.ref != nil {
+		n.ref.validate(data, path, errs)
+	}
+
+	if len(n.types) > 0 && !matchesAnyType(data, n.types) {
+		if len(n.types) == 1 {
+			report("expected %s, got %s", n.types[0], jsonTypeOf(data))
+		} else {
+			report("expected one of %v, got %s", n.types, jsonTypeOf(data))
 		}
-		if num, ok := data.(int); ok {
-			return validateEnumProperty[int](num, schema.Enum, func(value int, enumValue string) bool {
-				if enumNum, err := strconv.Atoi(enumValue); err == nil && value == enumNum {
-					return true
-				}
-				return false
-			})
-		}
-		return false
-	case Boolean:
-		_, ok := data.(bool)
-		return ok
-	case Integer:
-		// Golang unmarshals all numbers as float64, so we need to check if the float64 is an integer
-		if num, ok := data.(float64); ok {
-			if num == float64(int64(num)) {
-				return validateEnumProperty[float64](num, schema.Enum, func(value float64, enumValue string) bool {
-					if enumNum, err := strconv.ParseFloat(enumValue, 64); err == nil && value == enumNum {
-						return true
-					}
-					return false
-				})
-			}
-			return false
+		// the other keywords would only repeat the type mismatch
+		return
+	}
+
+	if n.enum != nil && !containsJSON(n.enum, data) {
+		report("value %s is not one of %s", encodeJSON(data), encodeJSON(n.enum))
+	}
+	if n.hasConst && !equalJSON(n.constant, data) {
+		report("value %s is not %s", encodeJSON(data), encodeJSON(n.constant))
+	}
+
+	switch v := data.(type) {
+	case string:
+		n.validateString(v, report)
+	case map[string]any:
+		n.validateObject(v, path, errs, report)
+	case []any:
+		n.validateArray(v, path, errs, report)
+	default:
+		if number, ok := toNumber(data); ok {
+			n.validateNumber(number, report)
 		}
+	}
 
-		if num, ok := data.(int); ok {
-			return validateEnumProperty[int](num, schema.Enum, func(value int, enumValue string) bool {
-				if enumNum, err := strconv.Atoi(enumValue); err == nil && value == enumNum {
-					return true
-				}
-				return false
-			})
+	n.validateCombinators(data, path, errs, report)
+}
+
+func (n *schemaNode) validateNumber(number float64, report func(string, ...any)) {
+	if n.minimum != nil && number < *n.minimum {
+		report("%v is less than the minimum %v", number, *n.minimum)
+	}
+	if n.maximum != nil && number > *n.maximum {
+		report("%v is greater than the maximum %v", number, *n.maximum)
+	}
+	if n.exclusiveMinimum != nil && number <= *n.exclusiveMinimum {
+		report("%v must be greater than %v", number, *n.exclusiveMinimum)
+	}
+	if n.exclusiveMaximum != nil && number >= *n.exclusiveMaximum {
+		report("%v must be less than %v", number, *n.exclusiveMaximum)
+	}
+	if n.multipleOf != nil && *n.multipleOf > 0 {
+		if q := number / *n.multipleOf; q != math.Trunc(q) {
+			report("%v is not a multiple of %v", number, *n.multipleOf)
+		}
+	}
+}
+
+func (n *schemaNode) validateString(str string, report func(string, ...any)) {
+	length := utf8.RuneCountInString(str)
+	if n.minLength != nil && length < *n.minLength {
+		report("length %d is shorter than the minimum length %d", length, *n.minLength)
+	}
+	if n.maxLength != nil && length > *n.maxLength {
+		report("length %d is longer than the maximum length %d", length, *n.maxLength)
+	}
+	if n.pattern != nil && !n.pattern.MatchString(str) {
+		report("%q does not match the pattern %q", str, n.pattern.String())
+	}
+	if n.format != "" && !matchesFormat(n.format, str) {
+		report("%q is not a valid %s", str, n.format)
+	}
+}
+
+func (n *schemaNode) validateObject(object map[string]any, path string, errs *ValidationErrors, report func(string, ...any)) {
+	for _, field := range n.required {
+		if _, exists := object[field]; !exists {
+			*errs = append(*errs, &ValidationError{Path: path + "/" + escapePointer(field), Reason: "required property is missing"})
+		}
+	}
+	if n.minProperties != nil && len(object) < *n.minProperties {
+		report("has %d properties, at least %d are required", len(object), *n.minProperties)
+	}
+	if n.maxProperties != nil && len(object) > *n.maxProperties {
+		report("has %d properties, at most %d are allowed", len(object), *n.maxProperties)
+	}
+
+	for _, name := range n.propertyNames {
+		if value, exists := object[name]; exists {
+			n.properties[name].validate(value, path+"/"+escapePointer(name), errs)
+		}
+	}
+
+	if n.additionalProperties == nil {
+		return
+	}
+	additional := make([]string, 0)
+	for name := range object {
+		if _, declared := n.properties[name]; !declared {
+			additional = append(additional, name)
+		}
+	}
+	sort.Strings(additional)
+	for _, name := range additional {
+		if n.additionalProperties.alwaysFalse {
+			*errs = append(*errs, &ValidationError{Path: path + "/" + escapePointer(name), Reason: "property is not allowed"})
+			continue
 		}
+		n.additionalProperties.validate(object[name], path+"/"+escapePointer(name), errs)
+	}
+}
 
-		if num, ok := data.(int64); ok {
-			return validateEnumProperty[int64](num, schema.Enum, func(value int64, enumValue string) bool {
-				if enumNum, err := strconv.Atoi(enumValue); err == nil && value == int64(enumNum) {
-					return true
+func (n *schemaNode) validateArray(array []any, path string, errs *ValidationErrors, report func(string, ...any)) {
+	if n.minItems != nil && len(array) < *n.minItems {
+		report("has %d items, at least %d are required", len(array), *n.minItems)
+	}
+	if n.maxItems != nil && len(array) > *n.maxItems {
+		report("has %d items, at most %d are allowed", len(array), *n.maxItems)
+	}
+	if n.uniqueItems {
+	unique:
+		for i := range array {
+			for j := 0; j < i; j++ {
+				if equalJSON(array[i], array[j]) {
+					report("items %d and %d are equal, items must be unique", j, i)
+					break unique
 				}
-				return false
-			})
+			}
+		}
+	}
+	if n.items != nil {
+		for i, item := range array {
+			n.items.validate(item, path+"/"+strconv.Itoa(i), errs)
 		}
-		return false
-	case Null:
-		return data == nil
-	default:
-		return false
 	}
 }
 
-func validateObject(schema Property, data any) bool {
-	dataMap, ok := data.(map[string]any)
-	if !ok {
-		return false
+func (n *schemaNode) validateCombinators(data any, path string, errs *ValidationErrors, report func(string, ...any)) {
+	for _, schema := range n.allOf {
+		schema.validate(data, path, errs)
 	}
-	for _, field := range schema.Required {
-		if _, exists := dataMap[field]; !exists {
-			return false
+
+	if len(n.anyOf) > 0 {
+		matched := false
+		var best ValidationErrors
+		for _, schema := range n.anyOf {
+			branchErrs := schema.collect(data, path)
+			if len(branchErrs) == 0 {
+				matched = true
+				break
+			}
+			if best == nil || len(branchErrs) < len(best) {
+				best = branchErrs
+			}
+		}
+		if !matched {
+			report("does not match any of the anyOf schemas, closest mismatch: %s", best.Error())
 		}
 	}
-	for key, valueSchema := range schema.Properties {
-		value, exists := dataMap[key]
-		if exists && !validate(*valueSchema, value) {
-			return false
+
+	if len(n.oneOf) > 0 {
+		var matches []int
+		var best ValidationErrors
+		for i, schema := range n.oneOf {
+			branchErrs := schema.collect(data, path)
+			if len(branchErrs) == 0 {
+				matches = append(matches, i)
+			} else if best == nil || len(branchErrs) < len(best) {
+				best = branchErrs
+			}
+		}
+		switch {
+		case len(matches) == 0:
+			report("does not match any of the oneOf schemas, closest mismatch: %s", best.Error())
+		case len(matches) > 1:
+			report("matches the oneOf schemas %v, exactly one match is allowed", matches)
 		}
 	}
-	return true
+
+	if n.not != nil && len(n.not.collect(data, path)) == 0 {
+		report("must not match the schema in not")
+	}
 }
 
-func validateArray(schema Property, data any) bool {
-	dataArray, ok := data.([]any)
-	if !ok {
-		return false
+// collect returns the mismatches between data and the schema, without reporting them
+func (n *schemaNode) collect(data any, path string) ValidationErrors {
+	var errs ValidationErrors
+	n.validate(data, path, &errs)
+	return errs
+}
+
+func matchesAnyType(data any, types []DataType) bool {
+	for _, t := range types {
+		switch t {
+		case Integer:
+			if number, ok := toNumber(data); ok && number == math.Trunc(number) {
+				return true
+			}
+		case Number:
+			if _, ok := toNumber(data); ok {
+				return true
+			}
+		default:
+			if jsonTypeOf(data) == string(t) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+var (
+	uuidRegexp     = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	hostnameRegexp = regexp.MustCompile(`^(?i)[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?(\.[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?)*$`)
+)
+
+// matchesFormat checks the formats in common use, unknown formats are only annotations and always match
+func matchesFormat(format, str string) bool {
+	switch format {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, str)
+		return err == nil
+	case "date":
+		_, err := time.Parse("2006-01-02", str)
+		return err == nil
+	case "time":
+		_, err := time.Parse("15:04:05.999999999Z07:00", str)
+		return err == nil
+	case "email":
+		addr, err := mail.ParseAddress(str)
+		return err == nil && addr.Address == str
+	case "uri":
+		u, err := url.Parse(str)
+		return err == nil && u.Scheme != ""
+	case "uri-reference":
+		_, err := url.Parse(str)
+		return err == nil
+	case "uuid":
+		return uuidRegexp.MatchString(str)
+	case "ipv4":
+		ip := net.ParseIP(str)
+		return ip != nil && ip.To4() != nil && !strings.Contains(str, ":")
+	case "ipv6":
+		ip := net.ParseIP(str)
+		return ip != nil && strings.Contains(str, ":")
+	case "hostname":
+		return len(str) <= 253 && hostnameRegexp.MatchString(str)
+	case "regex":
+		_, err := regexp.Compile(str)
+		return err == nil
+	default:
+		return true
+	}
+}
+
+// jsonTypeOf names the JSON type of a value decoded by encoding/json
+func jsonTypeOf(data any) string {
+	switch data.(type) {
+	case nil:
+		return string(Null)
+	case bool:
+		return string(Boolean)
+	case string:
+		return string(String)
+	case []any:
+		return string(Array)
+	case map[string]any:
+		return string(ObjectT)
+	}
+	if _, ok := toNumber(data); ok {
+		return string(Number)
+	}
+	return fmt.Sprintf("%T", data)
+}
+
+func toNumber(data any) (float64, bool) {
+	switch v := data.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// equalJSON compares two JSON values, numbers are equal when their values are, whatever their Go type
+func equalJSON(a, b any) bool {
+	if x, ok := toNumber(a); ok {
+		y, ok := toNumber(b)
+		return ok && x == y
 	}
-	for _, item := range dataArray {
-		if !validate(*schema.Items, item) {
+
+	switch x := a.(type) {
+	case []any:
+		y, ok := b.([]any)
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !equalJSON(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]any:
+		y, ok := b.(map[string]any)
+		if !ok || len(x) != len(y) {
 			return false
 		}
+		for k, v := range x {
+			w, ok := y[k]
+			if !ok || !equalJSON(v, w) {
+				return false
+			}
+		}
+		return true
+	default:
+		return a == b
 	}
-	return true
 }
 
-func validateEnumProperty[T any](data T, enum []string, compareFunc func(T, string) bool) bool {
-	for _, enumValue := range enum {
-		if compareFunc(data, enumValue) {
+func containsJSON(values []any, data any) bool {
+	for _, value := range values {
+		if equalJSON(value, data) {
 			return true
 		}
 	}
-	return len(enum) == 0
+	return false
+}
+
+func encodeJSON(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
 }
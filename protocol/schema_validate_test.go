@@ -2,6 +2,7 @@ package protocol
 
 import (
 	"encoding/json"
+	"errors"
 	"reflect"
 	"testing"
 )
@@ -219,8 +220,12 @@ func Test_Validate(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := validate(tt.args.schema, tt.args.data); got != tt.want {
-				t.Errorf("validate() = %v, want %v", got, tt.want)
+			schema, err := compileProperty(&tt.args.schema)
+			if err != nil {
+				t.Fatalf("compileProperty: %+v", err)
+			}
+			if got := schema.Validate(tt.args.data) == nil; got != tt.want {
+				t.Errorf("Validate() = %v, want %v", got, tt.want)
 			}
 		})
 	}
@@ -299,7 +304,11 @@ func TestUnmarshal(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := verifySchemaAndUnmarshal(tt.args.schema, tt.args.content, tt.args.v)
+			schema, err := compileProperty(&tt.args.schema)
+			if err != nil {
+				t.Fatalf("compileProperty: %+v", err)
+			}
+			err = verifyCompiledSchemaAndUnmarshal(schema, tt.args.content, tt.args.v)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Unmarshal() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -457,3 +466,121 @@ func TestVerifyAndUnmarshal(t *testing.T) {
 		})
 	}
 }
+
+func TestCompileSchema(t *testing.T) {
+	schema, err := CompileSchema(json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"path": {"type": "string"},
+			"lines": {"type": "array", "items": {"type": "integer"}},
+			"options": {"type": "object", "properties": {"mode/kind": {"type": "string", "enum": ["r", "w"]}}},
+			"extra": {}
+		},
+		"required": ["path"]
+	}`))
+	if err != nil {
+		t.Fatalf("CompileSchema: %+v", err)
+	}
+
+	tests := []struct {
+		content   string
+		wantPaths []string
+	}{
+		{`{"path": "a.txt", "lines": [1, 2], "options": {"mode/kind": "r"}, "extra": [null]}`, nil},
+		{`{"lines": [1]}`, []string{"/path"}},
+		{`{"path": 1}`, []string{"/path"}},
+		{`{"path": "a.txt", "lines": [1, 2.5]}`, []string{"/lines/1"}},
+		{`{"path": "a.txt", "options": {"mode/kind": "x"}}`, []string{"/options/mode~1kind"}},
+		{`{"path": 1, "lines": ["a", 2, "b"]}`, []string{"/lines/0", "/lines/2", "/path"}},
+		{`[]`, []string{""}},
+	}
+	for _, tt := range tests {
+		testValidationErrors(t, schema, tt.content, tt.wantPaths)
+	}
+
+	for _, invalid := range []string{
+		`{"type": "object", "properties": {"a": {"type": "text"}}}`,
+		`{"type": "string", "pattern": "("}`,
+		`{"$ref": "#/$defs/missing"}`,
+		`{"minLength": -1}`,
+		`{"type": "number", "minimum": 0, "exclusiveMinimum": true}`,
+		`"object"`,
+	} {
+		if _, err = CompileSchema(json.RawMessage(invalid)); err == nil {
+			t.Errorf("CompileSchema(%s) should fail", invalid)
+		}
+	}
+}
+
+func TestCompiledSchemaKeywords(t *testing.T) {
+	tests := []struct {
+		name      string
+		schema    string
+		content   string
+		wantPaths []string
+	}{
+		{"type_union", `{"type": ["string", "null"]}`, `null`, nil},
+		{"type_union_mismatch", `{"type": ["string", "null"]}`, `1`, []string{""}},
+		{"const", `{"const": {"a": [1, 2]}}`, `{"a": [1.0, 2]}`, nil},
+		{"const_mismatch", `{"const": "x"}`, `"y"`, []string{""}},
+		{"enum_mixed", `{"enum": [1, "a", null]}`, `null`, nil},
+		{"minimum_maximum", `{"type": "number", "minimum": 1, "exclusiveMaximum": 10}`, `10`, []string{""}},
+		{"multiple_of", `{"type": "integer", "multipleOf": 5}`, `12`, []string{""}},
+		{"length", `{"type": "string", "minLength": 2, "maxLength": 3}`, `"日本語"`, nil},
+		{"length_too_long", `{"type": "string", "maxLength": 2}`, `"abc"`, []string{""}},
+		{"pattern", `{"type": "string", "pattern": "^[a-z]+$"}`, `"abc1"`, []string{""}},
+		{"format_email", `{"type": "string", "format": "email"}`, `"not-an-email"`, []string{""}},
+		{"format_date_time", `{"type": "string", "format": "date-time"}`, `"2025-06-18T10:00:00Z"`, nil},
+		{"format_time_fraction", `{"type": "string", "format": "time"}`, `"10:00:00.125+08:00"`, nil},
+		{"format_time_mismatch", `{"type": "string", "format": "time"}`, `"10:00"`, []string{""}},
+		{"format_unknown", `{"type": "string", "format": "color"}`, `"anything"`, nil},
+		{"additional_properties_false", `{"type": "object", "properties": {"a": {}}, "additionalProperties": false}`,
+			`{"a": 1, "b": 2, "c": 3}`, []string{"/b", "/c"}},
+		{"additional_properties_schema", `{"type": "object", "additionalProperties": {"type": "integer"}}`,
+			`{"a": 1, "b": "2"}`, []string{"/b"}},
+		{"items_count", `{"type": "array", "minItems": 2, "uniqueItems": true}`, `[1, 1]`, []string{""}},
+		{"all_of", `{"allOf": [{"required": ["a"]}, {"required": ["b"]}]}`, `{}`, []string{"/a", "/b"}},
+		{"any_of", `{"anyOf": [{"type": "string"}, {"type": "integer"}]}`, `1`, nil},
+		{"any_of_mismatch", `{"anyOf": [{"type": "string"}, {"type": "integer"}]}`, `1.5`, []string{""}},
+		{"one_of_ambiguous", `{"oneOf": [{"type": "number"}, {"type": "integer"}]}`, `1`, []string{""}},
+		{"not", `{"not": {"type": "null"}}`, `null`, []string{""}},
+		{"ref_defs", `{"$defs": {"id": {"type": "string", "format": "uuid"}}, "properties": {"id": {"$ref": "#/$defs/id"}}}`,
+			`{"id": "42"}`, []string{"/id"}},
+		{"ref_recursive", `{"type": "object", "properties": {"name": {"type": "string"}, "children": {"type": "array", "items": {"$ref": "#"}}}}`,
+			`{"name": "root", "children": [{"name": "a", "children": [{"name": 1}]}]}`, []string{"/children/0/children/0/name"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema, err := CompileSchema(json.RawMessage(tt.schema))
+			if err != nil {
+				t.Fatalf("CompileSchema: %+v", err)
+			}
+			testValidationErrors(t, schema, tt.content, tt.wantPaths)
+		})
+	}
+}
+
+func testValidationErrors(t *testing.T, schema *CompiledSchema, content string, wantPaths []string) {
+	t.Helper()
+
+	err := schema.ValidateJSON(json.RawMessage(content))
+	if wantPaths == nil {
+		if err != nil {
+			t.Errorf("ValidateJSON(%s) = %v, want nil", content, err)
+		}
+		return
+	}
+
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Errorf("ValidateJSON(%s) = %v, want ValidationErrors", content, err)
+		return
+	}
+	paths := make([]string, 0, len(errs))
+	for _, e := range errs {
+		paths = append(paths, e.Path)
+	}
+	if !reflect.DeepEqual(paths, wantPaths) {
+		t.Errorf("ValidateJSON(%s) paths = %q, want %q (%v)", content, paths, wantPaths, err)
+	}
+}
@@ -8,12 +8,16 @@ import (
 )
 
 // ListToolsRequest represents a request to list available tools
-type ListToolsRequest struct{}
+type ListToolsRequest struct {
+	Meta map[string]interface{} `json:"_meta,omitempty"`
+	PaginatedRequest
+}
 
 // ListToolsResult represents the response to a list tools request
 type ListToolsResult struct {
-	Tools      []*Tool `json:"tools"`
-	NextCursor string  `json:"nextCursor,omitempty"`
+	Meta       map[string]interface{} `json:"_meta,omitempty"`
+	Tools      []*Tool                `json:"tools"`
+	NextCursor string                 `json:"nextCursor,omitempty"`
 }
 
 // Tool represents a tool definition that the client can call
@@ -21,6 +25,9 @@ type Tool struct {
 	// Name is the unique identifier of the tool
 	Name string `json:"name"`
 
+	// Title is a human-readable name of the tool intended for display
+	Title string `json:"title,omitempty"`
+
 	// Description is a human-readable description of the tool
 	Description string `json:"description,omitempty"`
 
@@ -28,15 +35,29 @@ type Tool struct {
 	InputSchema InputSchema `json:"inputSchema"`
 
 	RawInputSchema json.RawMessage `json:"-"`
+
+	// OutputSchema defines the structure of CallToolResult.StructuredContent using JSON Schema
+	OutputSchema *OutputSchema `json:"outputSchema,omitempty"`
+
+	RawOutputSchema json.RawMessage `json:"-"`
+
+	// Annotations describe the behavior of the tool, clients should treat them as untrusted hints
+	Annotations *ToolAnnotations `json:"annotations,omitempty"`
 }
 
 func (t *Tool) MarshalJSON() ([]byte, error) {
-	m := make(map[string]interface{}, 3)
+	m := make(map[string]interface{}, 6)
 
 	m["name"] = t.Name
+	if t.Title != "" {
+		m["title"] = t.Title
+	}
 	if t.Description != "" {
 		m["description"] = t.Description
 	}
+	if t.Annotations != nil {
+		m["annotations"] = t.Annotations
+	}
 
 	// Determine which schema to use
 	if t.RawInputSchema != nil {
@@ -49,11 +70,120 @@ func (t *Tool) MarshalJSON() ([]byte, error) {
 		m["inputSchema"] = t.InputSchema
 	}
 
+	if t.RawOutputSchema != nil {
+		if t.OutputSchema != nil {
+			return nil, fmt.Errorf("outputSchema field conflict")
+		}
+		m["outputSchema"] = t.RawOutputSchema
+	} else if t.OutputSchema != nil {
+		m["outputSchema"] = t.OutputSchema
+	}
+
 	return json.Marshal(m)
 }
 
+// HasOutputSchema reports whether the tool declares the structure of its results
+func (t *Tool) HasOutputSchema() bool {
+	return t.OutputSchema != nil || t.RawOutputSchema != nil
+}
+
+// VerifyStructuredContent checks structuredContent against the output schema of the tool,
+// a tool without output schema accepts anything.
+func (t *Tool) VerifyStructuredContent(structuredContent interface{}) error {
+	if !t.HasOutputSchema() {
+		return nil
+	}
+	schema, err := t.CompileOutputSchema()
+	if err != nil {
+		return fmt.Errorf("invalid output schema: %w", err)
+	}
+
+	content, err := json.Marshal(structuredContent)
+	if err != nil {
+		return err
+	}
+	if err = schema.ValidateJSON(content); err != nil {
+		return fmt.Errorf("structured content validation failed against the output schema: %w", err)
+	}
+	return nil
+}
+
+// CompileOutputSchema compiles the output schema of the tool, RawOutputSchema is used when it is set
+func (t *Tool) CompileOutputSchema() (*CompiledSchema, error) {
+	if t.RawOutputSchema != nil {
+		return CompileSchema(t.RawOutputSchema)
+	}
+
+	schema, err := json.Marshal(t.OutputSchema)
+	if err != nil {
+		return nil, err
+	}
+	return CompileSchema(schema)
+}
+
+// CompileInputSchema compiles the input schema of the tool, RawInputSchema is used when it is set
+func (t *Tool) CompileInputSchema() (*CompiledSchema, error) {
+	if t.RawInputSchema != nil {
+		return CompileSchema(t.RawInputSchema)
+	}
+
+	schema, err := json.Marshal(t.InputSchema)
+	if err != nil {
+		return nil, err
+	}
+	return CompileSchema(schema)
+}
+
+// ToolAnnotations additional properties describing a Tool to clients.
+// Unset hints take the defaults defined by the specification.
+type ToolAnnotations struct {
+	// Title a human-readable title for the tool
+	Title string `json:"title,omitempty"`
+
+	// ReadOnlyHint if true, the tool does not modify its environment. Default: false
+	ReadOnlyHint *bool `json:"readOnlyHint,omitempty"`
+
+	// DestructiveHint if true, the tool may perform destructive updates to its environment,
+	// if false, the tool performs only additive updates. Only meaningful when ReadOnlyHint is false. Default: true
+	DestructiveHint *bool `json:"destructiveHint,omitempty"`
+
+	// IdempotentHint if true, calling the tool repeatedly with the same arguments will have no additional effect.
+	// Only meaningful when ReadOnlyHint is false. Default: false
+	IdempotentHint *bool `json:"idempotentHint,omitempty"`
+
+	// OpenWorldHint if true, the tool may interact with an "open world" of external entities,
+	// if false, the tool's domain of interaction is closed. Default: true
+	OpenWorldHint *bool `json:"openWorldHint,omitempty"`
+}
+
+// IsReadOnly reports the effective value of ReadOnlyHint
+func (a *ToolAnnotations) IsReadOnly() bool {
+	return a != nil && a.ReadOnlyHint != nil && *a.ReadOnlyHint
+}
+
+// IsDestructive reports whether the tool may perform destructive updates, taking the defaults into account
+func (a *ToolAnnotations) IsDestructive() bool {
+	if a.IsReadOnly() {
+		return false
+	}
+	return a == nil || a.DestructiveHint == nil || *a.DestructiveHint
+}
+
+// IsIdempotent reports the effective value of IdempotentHint
+func (a *ToolAnnotations) IsIdempotent() bool {
+	return a != nil && a.IdempotentHint != nil && *a.IdempotentHint
+}
+
+// IsOpenWorld reports the effective value of OpenWorldHint
+func (a *ToolAnnotations) IsOpenWorld() bool {
+	return a == nil || a.OpenWorldHint == nil || *a.OpenWorldHint
+}
+
 type InputSchemaType string
 
+// OutputSchema represents a JSON Schema object describing the structured result of a tool, it has the same shape as InputSchema
+type OutputSchema = InputSchema
+
 const Object InputSchemaType = "object"
 
 // InputSchema represents a JSON Schema object defining the expected parameters for a tool
@@ -61,10 +191,13 @@ type InputSchema struct {
 	Type       InputSchemaType      `json:"type"`
 	Properties map[string]*Property `json:"properties,omitempty"`
 	Required   []string             `json:"required,omitempty"`
+	// Defs holds the schemas of the named struct types, referenced by the properties with "#/$defs/<name>"
+	Defs map[string]*Property `json:"$defs,omitempty"`
 }
 
 // CallToolRequest represents a request to call a specific tool
 type CallToolRequest struct {
+	Meta         map[string]interface{} `json:"_meta,omitempty"`
 	Name         string                 `json:"name"`
 	Arguments    map[string]interface{} `json:"arguments,omitempty"`
 	RawArguments json.RawMessage        `json:"-"`
@@ -118,8 +251,11 @@ func (r *CallToolRequest) MarshalJSON() ([]byte, error) {
 
 // CallToolResult represents the response to a tool call
 type CallToolResult struct {
-	Content []Content `json:"content"`
-	IsError bool      `json:"isError,omitempty"`
+	Meta    map[string]interface{} `json:"_meta,omitempty"`
+	Content []Content              `json:"content"`
+	// StructuredContent is the machine-readable result of the tool, it conforms to Tool.OutputSchema when the tool declares one
+	StructuredContent interface{} `json:"structuredContent,omitempty"`
+	IsError           bool        `json:"isError,omitempty"`
 }
 
 // UnmarshalJSON implements the json.Unmarshaler interface for CallToolResult
@@ -135,67 +271,127 @@ func (r *CallToolResult) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
-	r.Content = make([]Content, len(aux.Content))
-	for i, content := range aux.Content {
-		// Try to unmarshal content as TextContent first
-		var textContent *TextContent
-		if err := pkg.JSONUnmarshal(content, &textContent); err == nil {
-			r.Content[i] = textContent
-			continue
-		}
+	contents, err := unmarshalContents(aux.Content)
+	if err != nil {
+		return err
+	}
+	r.Content = contents
+	return nil
+}
 
-		// Try to unmarshal content as ImageContent
-		var imageContent *ImageContent
-		if err := pkg.JSONUnmarshal(content, &imageContent); err == nil {
-			r.Content[i] = imageContent
-			continue
-		}
+// ToolListChangedNotification represents a notification that the tool list has changed
+type ToolListChangedNotification struct {
+	Meta map[string]interface{} `json:"_meta,omitempty"`
+}
 
-		// Try to unmarshal content as AudioContent
-		var audioContent *AudioContent
-		if err := pkg.JSONUnmarshal(content, &audioContent); err == nil {
-			r.Content[i] = audioContent
-			continue
-		}
+// ToolOption represents an option for creating a tool
+type ToolOption func(*Tool)
 
-		// Try to unmarshal content as embeddedResource
-		var embeddedResource *EmbeddedResource
-		if err := pkg.JSONUnmarshal(content, &embeddedResource); err == nil {
-			r.Content[i] = embeddedResource
-			return nil
-		}
+// WithToolTitle sets the display title of the tool
+func WithToolTitle(title string) ToolOption {
+	return func(t *Tool) {
+		t.Title = title
+	}
+}
 
-		return fmt.Errorf("unknown content type at index %d", i)
+// WithToolAnnotations sets all annotations of the tool at once
+func WithToolAnnotations(annotations ToolAnnotations) ToolOption {
+	return func(t *Tool) {
+		t.Annotations = &annotations
 	}
+}
 
-	return nil
+// WithReadOnlyHint sets annotations.readOnlyHint
+func WithReadOnlyHint(readOnly bool) ToolOption {
+	return func(t *Tool) {
+		t.annotations().ReadOnlyHint = &readOnly
+	}
 }
 
-// ToolListChangedNotification represents a notification that the tool list has changed
-type ToolListChangedNotification struct {
-	Meta map[string]interface{} `json:"_meta,omitempty"`
+// WithDestructiveHint sets annotations.destructiveHint
+func WithDestructiveHint(destructive bool) ToolOption {
+	return func(t *Tool) {
+		t.annotations().DestructiveHint = &destructive
+	}
+}
+
+// WithIdempotentHint sets annotations.idempotentHint
+func WithIdempotentHint(idempotent bool) ToolOption {
+	return func(t *Tool) {
+		t.annotations().IdempotentHint = &idempotent
+	}
+}
+
+// WithOpenWorldHint sets annotations.openWorldHint
+func WithOpenWorldHint(openWorld bool) ToolOption {
+	return func(t *Tool) {
+		t.annotations().OpenWorldHint = &openWorld
+	}
+}
+
+// WithOutputStruct sets the output schema generated from outputReqStruct, the same way NewTool generates the input schema.
+// It panics if the schema can't be generated, use GenerateOutputSchema to handle that error instead.
+func WithOutputStruct(outputReqStruct interface{}) ToolOption {
+	schema, err := GenerateOutputSchema(outputReqStruct)
+	if err != nil {
+		panic(err)
+	}
+	return func(t *Tool) {
+		t.OutputSchema = schema
+	}
+}
+
+// GenerateOutputSchema generates an output schema from outputReqStruct
+func GenerateOutputSchema(outputReqStruct interface{}) (*OutputSchema, error) {
+	schema, err := generateSchemaFromReqStruct(outputReqStruct)
+	if err != nil {
+		return nil, fmt.Errorf("generate output schema: %w", err)
+	}
+	return schema, nil
+}
+
+// WithRawOutputSchema sets an output schema written by hand
+func WithRawOutputSchema(schema json.RawMessage) ToolOption {
+	return func(t *Tool) {
+		t.RawOutputSchema = schema
+	}
+}
+
+func (t *Tool) annotations() *ToolAnnotations {
+	if t.Annotations == nil {
+		t.Annotations = &ToolAnnotations{}
+	}
+	return t.Annotations
 }
 
 // NewTool create a tool
-func NewTool(name string, description string, inputReqStruct interface{}) (*Tool, error) {
+func NewTool(name string, description string, inputReqStruct interface{}, opts ...ToolOption) (*Tool, error) {
 	schema, err := generateSchemaFromReqStruct(inputReqStruct)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Tool{
+	tool := &Tool{
 		Name:        name,
 		Description: description,
 		InputSchema: *schema,
-	}, nil
+	}
+	for _, opt := range opts {
+		opt(tool)
+	}
+	return tool, nil
 }
 
-func NewToolWithRawSchema(name, description string, schema json.RawMessage) *Tool {
-	return &Tool{
+func NewToolWithRawSchema(name, description string, schema json.RawMessage, opts ...ToolOption) *Tool {
+	tool := &Tool{
 		Name:           name,
 		Description:    description,
 		RawInputSchema: schema,
 	}
+	for _, opt := range opts {
+		opt(tool)
+	}
+	return tool
 }
 
 // NewListToolsRequest creates a new list tools request
@@ -203,6 +399,12 @@ func NewListToolsRequest() *ListToolsRequest {
 	return &ListToolsRequest{}
 }
 
+// WithCursor sets the cursor of the page to list, as returned in NextCursor by the previous page
+func (r *ListToolsRequest) WithCursor(cursor string) *ListToolsRequest {
+	r.Cursor = cursor
+	return r
+}
+
 // NewListToolsResult creates a new list tools response
 func NewListToolsResult(tools []*Tool, nextCursor string) *ListToolsResult {
 	return &ListToolsResult{
@@ -234,6 +436,21 @@ func NewCallToolResult(content []Content, isError bool) *CallToolResult {
 	}
 }
 
+// NewCallToolResultWithStructuredContent creates a new call tool response carrying structuredContent,
+// the JSON encoding of structuredContent is also returned as text content for clients that don't read structured results.
+func NewCallToolResultWithStructuredContent(structuredContent interface{}, isError bool) (*CallToolResult, error) {
+	text, err := json.Marshal(structuredContent)
+	if err != nil {
+		return nil, fmt.Errorf("marshal structured content: %w", err)
+	}
+
+	return &CallToolResult{
+		Content:           []Content{&TextContent{Type: "text", Text: string(text)}},
+		StructuredContent: structuredContent,
+		IsError:           isError,
+	}, nil
+}
+
 // NewToolListChangedNotification creates a new tool list changed notification
 func NewToolListChangedNotification() *ToolListChangedNotification {
 	return &ToolListChangedNotification{}
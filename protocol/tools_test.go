@@ -0,0 +1,115 @@
+package protocol
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestToolAnnotationsJSON(t *testing.T) {
+	type args struct {
+		Name string `json:"name"`
+	}
+
+	tool, err := NewTool("delete_file", "delete a file", args{},
+		WithToolTitle("Delete File"), WithReadOnlyHint(false), WithDestructiveHint(true), WithOpenWorldHint(false))
+	if err != nil {
+		t.Fatalf("NewTool: %+v", err)
+	}
+
+	b, err := json.Marshal(tool)
+	if err != nil {
+		t.Fatalf("json Marshal: %+v", err)
+	}
+
+	var got Tool
+	if err = json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("json Unmarshal: %+v", err)
+	}
+	if !reflect.DeepEqual(&got, tool) {
+		t.Fatalf("tool not as expected.\ngot  = %+v\nwant = %+v", got, tool)
+	}
+}
+
+func TestToolAnnotationsDefaults(t *testing.T) {
+	readOnly, notDestructive := true, false
+
+	tests := []struct {
+		name        string
+		annotations *ToolAnnotations
+		destructive bool
+		openWorld   bool
+	}{
+		{name: "no_annotations", annotations: nil, destructive: true, openWorld: true},
+		{name: "empty_annotations", annotations: &ToolAnnotations{}, destructive: true, openWorld: true},
+		{name: "read_only", annotations: &ToolAnnotations{ReadOnlyHint: &readOnly}, destructive: false, openWorld: true},
+		{name: "additive", annotations: &ToolAnnotations{DestructiveHint: &notDestructive}, destructive: false, openWorld: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.annotations.IsDestructive(); got != tt.destructive {
+				t.Errorf("IsDestructive() = %v, want %v", got, tt.destructive)
+			}
+			if got := tt.annotations.IsOpenWorld(); got != tt.openWorld {
+				t.Errorf("IsOpenWorld() = %v, want %v", got, tt.openWorld)
+			}
+		})
+	}
+}
+
+func TestToolStructuredContent(t *testing.T) {
+	type weatherReq struct {
+		City string `json:"city"`
+	}
+	type weatherResp struct {
+		Temperature float64 `json:"temperature"`
+		Conditions  string  `json:"conditions,omitempty"`
+	}
+
+	tool, err := NewTool("get_weather", "get weather", weatherReq{}, WithOutputStruct(weatherResp{}))
+	if err != nil {
+		t.Fatalf("NewTool: %+v", err)
+	}
+	expectedSchema := &OutputSchema{
+		Type:       Object,
+		Properties: map[string]*Property{"temperature": {Type: Number}, "conditions": {Type: String}},
+		Required:   []string{"temperature"},
+	}
+	if !reflect.DeepEqual(tool.OutputSchema, expectedSchema) {
+		t.Fatalf("output schema not as expected.\ngot  = %+v\nwant = %+v", tool.OutputSchema, expectedSchema)
+	}
+	rawTool := NewToolWithRawSchema("get_weather", "get weather", json.RawMessage(`{"type":"object"}`), WithOutputStruct(weatherResp{}))
+	if !reflect.DeepEqual(rawTool.OutputSchema, expectedSchema) {
+		t.Fatalf("raw schema tool output schema not as expected.\ngot  = %+v\nwant = %+v", rawTool.OutputSchema, expectedSchema)
+	}
+	if _, err = GenerateOutputSchema("not a struct"); err == nil {
+		t.Fatalf("GenerateOutputSchema should fail for a non struct")
+	}
+
+	result, err := NewCallToolResultWithStructuredContent(weatherResp{Temperature: 22.5, Conditions: "sunny"}, false)
+	if err != nil {
+		t.Fatalf("NewCallToolResultWithStructuredContent: %+v", err)
+	}
+	if err = tool.VerifyStructuredContent(result.StructuredContent); err != nil {
+		t.Fatalf("VerifyStructuredContent: %+v", err)
+	}
+	if err = tool.VerifyStructuredContent(map[string]interface{}{"conditions": "sunny"}); err == nil {
+		t.Fatalf("VerifyStructuredContent should fail without required temperature")
+	}
+
+	b, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("json Marshal: %+v", err)
+	}
+	var got CallToolResult
+	if err = json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("json Unmarshal: %+v", err)
+	}
+	expectedStructured := map[string]interface{}{"temperature": 22.5, "conditions": "sunny"}
+	if !reflect.DeepEqual(got.StructuredContent, expectedStructured) {
+		t.Fatalf("structured content not as expected.\ngot  = %+v\nwant = %+v", got.StructuredContent, expectedStructured)
+	}
+	if text, ok := got.Content[0].(*TextContent); !ok || text.Text != `{"temperature":22.5,"conditions":"sunny"}` {
+		t.Fatalf("text fallback not as expected: %+v", got.Content[0])
+	}
+}
@@ -1,10 +1,19 @@
 package protocol
 
-const Version = "2025-03-26"
+// Protocol revisions, the name of a revision is the date it was published
+const (
+	Version20241105 = "2024-11-05"
+	Version20250326 = "2025-03-26"
+	Version20250618 = "2025-06-18"
+)
+
+// Version is the newest protocol revision supported
+const Version = Version20250618
 
 var SupportedVersion = map[string]struct{}{
-	"2024-11-05": {},
-	"2025-03-26": {},
+	Version20241105: {},
+	Version20250326: {},
+	Version20250618: {},
 }
 
 // Method represents the JSON-RPC method name
@@ -42,6 +51,9 @@ const (
 	// Sampling related methods
 	SamplingCreateMessage Method = "sampling/createMessage"
 
+	// Elicitation related methods
+	ElicitationCreate Method = "elicitation/create"
+
 	// Logging related methods
 	LoggingSetLevel        Method = "logging/setLevel"
 	NotificationLogMessage Method = "notifications/message"
@@ -51,9 +63,43 @@ const (
 
 	// progress related methods
 	NotificationProgress  Method = "notifications/progress"
-	NotificationCancelled Method = "notifications/canceled"
+	NotificationCancelled Method = "notifications/cancelled"
 )
 
+var definedMethods = map[Method]struct{}{
+	Ping:                             {},
+	Initialize:                       {},
+	NotificationInitialized:          {},
+	RootsList:                        {},
+	NotificationRootsListChanged:     {},
+	ResourcesList:                    {},
+	ResourceListTemplates:            {},
+	ResourcesRead:                    {},
+	ResourcesSubscribe:               {},
+	ResourcesUnsubscribe:             {},
+	NotificationResourcesListChanged: {},
+	NotificationResourcesUpdated:     {},
+	ToolsList:                        {},
+	ToolsCall:                        {},
+	NotificationToolsListChanged:     {},
+	PromptsList:                      {},
+	PromptsGet:                       {},
+	NotificationPromptsListChanged:   {},
+	SamplingCreateMessage:            {},
+	ElicitationCreate:                {},
+	LoggingSetLevel:                  {},
+	NotificationLogMessage:           {},
+	CompletionComplete:               {},
+	NotificationProgress:             {},
+	NotificationCancelled:            {},
+}
+
+// IsDefined reports whether the method is defined by MCP rather than being a custom extension
+func (m Method) IsDefined() bool {
+	_, ok := definedMethods[m]
+	return ok
+}
+
 // Role represents the sender or recipient of messages and data in a conversation
 type Role string
 
@@ -86,6 +132,8 @@ var (
 	_ ClientResponse = &PingResult{}
 	_ ClientResponse = &ListToolsResult{}
 	_ ClientResponse = &CreateMessageResult{}
+	_ ClientResponse = &ListRootsResult{}
+	_ ClientResponse = &ElicitResult{}
 )
 
 type ClientNotify interface{}
@@ -103,6 +151,7 @@ var (
 	_ ServerRequest = &PingRequest{}
 	_ ServerRequest = &ListRootsRequest{}
 	_ ServerRequest = &CreateMessageRequest{}
+	_ ServerRequest = &ElicitRequest{}
 )
 
 type ServerResponse interface{}
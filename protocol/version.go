@@ -0,0 +1,103 @@
+package protocol
+
+import "fmt"
+
+// NegotiateVersion returns the protocol version to use with a peer that requested version:
+// the requested version when it is supported, otherwise the newest supported version.
+func NegotiateVersion(version string) string {
+	if _, ok := SupportedVersion[version]; ok {
+		return version
+	}
+	return Version
+}
+
+// VersionBefore reports whether version is an older protocol revision than other, an empty version is treated as the newest.
+func VersionBefore(version, other string) bool {
+	// revisions are named by date, so they compare lexically
+	return version != "" && version < other
+}
+
+// AdaptToVersion returns msg without the fields that peers speaking version don't know about,
+// msg itself is never modified, a copy is returned when something has to be removed.
+func AdaptToVersion(version string, msg interface{}) interface{} {
+	if !VersionBefore(version, Version) {
+		return msg
+	}
+
+	switch m := msg.(type) {
+	case *InitializeResult:
+		if VersionBefore(version, Version20250326) && m.Capabilities.Completions != nil {
+			result := *m
+			result.Capabilities.Completions = nil
+			return &result
+		}
+	case *ListToolsResult:
+		result := *m
+		result.Tools = make([]*Tool, 0, len(m.Tools))
+		for _, tool := range m.Tools {
+			result.Tools = append(result.Tools, adaptToolToVersion(version, tool))
+		}
+		return &result
+	case *CallToolResult:
+		result := *m
+		if VersionBefore(version, Version20250618) {
+			result.StructuredContent = nil
+		}
+		result.Content = make([]Content, 0, len(m.Content))
+		for _, content := range m.Content {
+			result.Content = append(result.Content, adaptContentToVersion(version, content))
+		}
+		return &result
+	case *GetPromptResult:
+		result := *m
+		result.Messages = make([]PromptMessage, 0, len(m.Messages))
+		for _, message := range m.Messages {
+			message.Content = adaptContentToVersion(version, message.Content)
+			result.Messages = append(result.Messages, message)
+		}
+		return &result
+	case *CreateMessageRequest:
+		request := *m
+		request.Messages = make([]SamplingMessage, 0, len(m.Messages))
+		for _, message := range m.Messages {
+			message.Content = adaptContentToVersion(version, message.Content)
+			request.Messages = append(request.Messages, message)
+		}
+		return &request
+	case *ProgressNotification:
+		if VersionBefore(version, Version20250326) && m.Message != "" {
+			notify := *m
+			notify.Message = ""
+			return &notify
+		}
+	}
+	return msg
+}
+
+// adaptContentToVersion replaces content of a type the peer doesn't know with a text describing it
+func adaptContentToVersion(version string, content Content) Content {
+	switch c := content.(type) {
+	case *AudioContent:
+		if VersionBefore(version, Version20250326) {
+			return &TextContent{Annotated: c.Annotated, Type: "text", Text: fmt.Sprintf("[audio content of type %s]", c.MimeType)}
+		}
+	case *ResourceLink:
+		if VersionBefore(version, Version20250618) {
+			return &TextContent{Annotated: c.Annotated, Type: "text", Text: fmt.Sprintf("[resource link %s: %s]", c.Name, c.URI)}
+		}
+	}
+	return content
+}
+
+func adaptToolToVersion(version string, tool *Tool) *Tool {
+	adapted := *tool
+	if VersionBefore(version, Version20250618) {
+		adapted.Title = ""
+		adapted.OutputSchema = nil
+		adapted.RawOutputSchema = nil
+	}
+	if VersionBefore(version, Version20250326) {
+		adapted.Annotations = nil
+	}
+	return &adapted
+}
@@ -0,0 +1,94 @@
+package protocol
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNegotiateVersion(t *testing.T) {
+	tests := []struct {
+		requested string
+		expected  string
+	}{
+		{requested: Version20241105, expected: Version20241105},
+		{requested: Version20250326, expected: Version20250326},
+		{requested: Version20250618, expected: Version20250618},
+		{requested: "2099-01-01", expected: Version},
+		{requested: "", expected: Version},
+	}
+	for _, tt := range tests {
+		if got := NegotiateVersion(tt.requested); got != tt.expected {
+			t.Errorf("NegotiateVersion(%q) = %q, want %q", tt.requested, got, tt.expected)
+		}
+	}
+}
+
+func TestAdaptToVersion(t *testing.T) {
+	readOnly := true
+	tool := &Tool{
+		Name:         "test_tool",
+		Title:        "Test Tool",
+		InputSchema:  InputSchema{Type: Object},
+		OutputSchema: &OutputSchema{Type: Object},
+		Annotations:  &ToolAnnotations{ReadOnlyHint: &readOnly},
+	}
+	result := NewListToolsResult([]*Tool{tool}, "")
+
+	tests := []struct {
+		version  string
+		expected *Tool
+	}{
+		{version: Version20250618, expected: tool},
+		{version: Version20250326, expected: &Tool{Name: "test_tool", InputSchema: InputSchema{Type: Object}, Annotations: tool.Annotations}},
+		{version: Version20241105, expected: &Tool{Name: "test_tool", InputSchema: InputSchema{Type: Object}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			got := AdaptToVersion(tt.version, result).(*ListToolsResult)
+			if !reflect.DeepEqual(got.Tools[0], tt.expected) {
+				t.Fatalf("tool not as expected.\ngot  = %+v\nwant = %+v", got.Tools[0], tt.expected)
+			}
+		})
+	}
+
+	if result.Tools[0] != tool || tool.Title != "Test Tool" || tool.Annotations == nil {
+		t.Fatalf("AdaptToVersion must not modify its input")
+	}
+}
+
+func TestAdaptContentToVersion(t *testing.T) {
+	audio := &AudioContent{Type: "audio", Data: []byte("wav"), MimeType: "audio/wav"}
+	result := NewCallToolResult([]Content{&TextContent{Type: "text", Text: "done"}, audio}, false)
+	prompt := NewGetPromptResult([]PromptMessage{{Role: RoleUser, Content: audio}}, "")
+
+	if got := AdaptToVersion(Version20250326, result).(*CallToolResult); got.Content[1] != audio {
+		t.Fatalf("audio content should be kept for %s: %+v", Version20250326, got.Content[1])
+	}
+
+	expected := &TextContent{Type: "text", Text: "[audio content of type audio/wav]"}
+	got := AdaptToVersion(Version20241105, result).(*CallToolResult)
+	if !reflect.DeepEqual(got.Content[1], expected) {
+		t.Fatalf("audio content not as expected.\ngot  = %+v\nwant = %+v", got.Content[1], expected)
+	}
+	if gotPrompt := AdaptToVersion(Version20241105, prompt).(*GetPromptResult); !reflect.DeepEqual(gotPrompt.Messages[0].Content, expected) {
+		t.Fatalf("prompt content not as expected.\ngot  = %+v\nwant = %+v", gotPrompt.Messages[0].Content, expected)
+	}
+	if result.Content[1] != audio || prompt.Messages[0].Content != audio {
+		t.Fatalf("AdaptToVersion must not modify its input")
+	}
+}
+
+func TestAdaptResourceLinkToVersion(t *testing.T) {
+	link := NewResourceLink(Resource{URI: "file:///a.txt", Name: "a.txt", Size: 10})
+	result := NewCallToolResult([]Content{link}, false)
+
+	if got := AdaptToVersion(Version20250618, result).(*CallToolResult); got.Content[0] != link {
+		t.Fatalf("resource link should be kept for %s: %+v", Version20250618, got.Content[0])
+	}
+	expected := &TextContent{Type: "text", Text: "[resource link a.txt: file:///a.txt]"}
+	for _, version := range []string{Version20250326, Version20241105} {
+		if got := AdaptToVersion(version, result).(*CallToolResult); !reflect.DeepEqual(got.Content[0], expected) {
+			t.Fatalf("%s: resource link not as expected.\ngot  = %+v\nwant = %+v", version, got.Content[0], expected)
+		}
+	}
+}
@@ -56,6 +56,70 @@ func (server *Server) Sampling(ctx context.Context, request *protocol.CreateMess
 	return &result, nil
 }
 
+// Elicit asks the user of the session carried by ctx for the input described by schemaStruct,
+// an accepted result is checked against the generated schema before it is returned.
+func (server *Server) Elicit(ctx context.Context, message string, schemaStruct interface{}) (*protocol.ElicitResult, error) {
+	sessionID, err := getSessionIDFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s, ok := server.sessionManager.GetSession(sessionID)
+	if !ok {
+		return nil, pkg.ErrLackSession
+	}
+
+	if s.GetClientCapabilities() == nil || s.GetClientCapabilities().Elicitation == nil {
+		return nil, pkg.ErrClientNotSupport
+	}
+
+	request, err := protocol.NewElicitRequest(message, schemaStruct)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := server.callClient(ctx, sessionID, protocol.ElicitationCreate, request)
+	if err != nil {
+		return nil, err
+	}
+
+	var result protocol.ElicitResult
+	if err = pkg.JSONUnmarshal(response, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	if err = request.VerifyContent(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (server *Server) ListRoots(ctx context.Context) (*protocol.ListRootsResult, error) {
+	sessionID, err := getSessionIDFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s, ok := server.sessionManager.GetSession(sessionID)
+	if !ok {
+		return nil, pkg.ErrLackSession
+	}
+
+	if s.GetClientCapabilities() == nil || s.GetClientCapabilities().Roots == nil {
+		return nil, pkg.ErrClientNotSupport
+	}
+
+	response, err := server.callClient(ctx, sessionID, protocol.RootsList, protocol.NewListRootsRequest())
+	if err != nil {
+		return nil, err
+	}
+
+	var result protocol.ListRootsResult
+	if err = pkg.JSONUnmarshal(response, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return &result, nil
+}
+
 func (server *Server) sendNotification4ToolListChanges(ctx context.Context) error {
 	if server.capabilities.Tools == nil || !server.capabilities.Tools.ListChanged {
 		return pkg.ErrServerNotSupport
@@ -121,6 +185,58 @@ func (server *Server) SendNotification4ResourcesUpdated(ctx context.Context, not
 	return pkg.JoinErrors(errList)
 }
 
+// Log sends a notifications/message to the client.
+// When ctx carries a session (as it does inside handlers) only that session is notified, otherwise every session is.
+// Sessions whose logging level is above level are skipped.
+func (server *Server) Log(ctx context.Context, level protocol.LoggingLevel, logger string, data interface{}) error {
+	if server.capabilities.Logging == nil {
+		return pkg.ErrServerNotSupport
+	}
+
+	notify := protocol.NewLogMessageNotificationWithData(level, logger, data)
+
+	if sessionID, err := getSessionIDFromCtx(ctx); err == nil && sessionID != "" {
+		s, ok := server.sessionManager.GetSession(sessionID)
+		if !ok {
+			return pkg.ErrLackSession
+		}
+		if !level.Enabled(s.GetLoggingLevel()) {
+			return nil
+		}
+		return server.sendMsgWithNotification(ctx, sessionID, protocol.NotificationLogMessage, notify)
+	}
+
+	var errList []error
+	server.sessionManager.RangeSessions(func(sessionID string, s *session.State) bool {
+		if !level.Enabled(s.GetLoggingLevel()) {
+			return true
+		}
+
+		if err := server.sendMsgWithNotification(ctx, sessionID, protocol.NotificationLogMessage, notify); err != nil {
+			errList = append(errList, fmt.Errorf("sessionID=%s, err: %w", sessionID, err))
+		}
+		return true
+	})
+	return pkg.JoinErrors(errList)
+}
+
+// ReportProgress sends a notifications/progress for the request being handled in ctx.
+// It does nothing when the client did not ask for progress by setting a progressToken in the request's _meta.
+func (server *Server) ReportProgress(ctx context.Context, progress, total float64, message string) error {
+	token, ok := GetProgressTokenFromCtx(ctx)
+	if !ok {
+		return nil
+	}
+
+	sessionID, err := getSessionIDFromCtx(ctx)
+	if err != nil {
+		return err
+	}
+
+	return server.sendMsgWithNotification(ctx, sessionID, protocol.NotificationProgress,
+		protocol.NewProgressNotificationWithMessage(token, progress, total, message))
+}
+
 // Responsible for request and response assembly
 func (server *Server) callClient(ctx context.Context, sessionID string, method protocol.Method, params protocol.ServerRequest) (json.RawMessage, error) {
 	session, ok := server.sessionManager.GetSession(sessionID)
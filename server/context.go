@@ -3,6 +3,8 @@ package server
 import (
 	"context"
 	"errors"
+
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
 )
 
 type sessionIDKey struct{}
@@ -18,3 +20,30 @@ func getSessionIDFromCtx(ctx context.Context) (string, error) {
 	}
 	return sessionID.(string), nil
 }
+
+type metaKey struct{}
+
+func setMetaToCtx(ctx context.Context, meta map[string]interface{}) context.Context {
+	return context.WithValue(ctx, metaKey{}, meta)
+}
+
+// GetMetaFromCtx returns the _meta the client attached to the request being handled, if any.
+func GetMetaFromCtx(ctx context.Context) (map[string]interface{}, bool) {
+	meta, ok := ctx.Value(metaKey{}).(map[string]interface{})
+	return meta, ok
+}
+
+type progressTokenKey struct{}
+
+func setProgressTokenToCtx(ctx context.Context, token protocol.ProgressToken) context.Context {
+	return context.WithValue(ctx, progressTokenKey{}, token)
+}
+
+// GetProgressTokenFromCtx returns the progressToken the client attached to the request being handled, if any.
+func GetProgressTokenFromCtx(ctx context.Context) (protocol.ProgressToken, bool) {
+	token := ctx.Value(progressTokenKey{})
+	if token == nil {
+		return nil, false
+	}
+	return token, true
+}
@@ -0,0 +1,47 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+)
+
+// MethodHandlerFunc handles a request whose method isn't part of MCP, its result is sent back as the response result
+type MethodHandlerFunc func(ctx context.Context, rawParams json.RawMessage) (interface{}, error)
+
+// NotificationHandlerFunc handles a notification whose method isn't part of MCP
+type NotificationHandlerFunc func(ctx context.Context, rawParams json.RawMessage) error
+
+// WithExperimentalCapability advertises a non-standard capability under capabilities.experimental during initialization
+func WithExperimentalCapability(name string, settings interface{}) Option {
+	return func(s *Server) {
+		if s.experimental == nil {
+			s.experimental = make(map[string]interface{})
+		}
+		s.experimental[name] = settings
+	}
+}
+
+// HandleMethod registers the handler of a custom request method, such as a vendor extension.
+// Requests go through the middlewares like any other. Methods defined by MCP are always handled by the server itself,
+// registering one of them is logged as an error and ignored.
+func (server *Server) HandleMethod(method string, handler MethodHandlerFunc) {
+	if protocol.Method(method).IsDefined() {
+		server.logger.Errorf("register method %s fail: method is defined by MCP", method)
+		return
+	}
+	server.methodHandlers.Store(method, handler)
+}
+
+// HandleNotification registers the handler of a custom notification method.
+// Unlike requests, notifications don't go through the middlewares and the handler's ctx isn't tied to any request,
+// so GetMetaFromCtx reports nothing: any _meta sent with the notification is left in rawParams.
+// Methods defined by MCP are always handled by the server itself, registering one of them is logged as an error and ignored.
+func (server *Server) HandleNotification(method string, handler NotificationHandlerFunc) {
+	if protocol.Method(method).IsDefined() {
+		server.logger.Errorf("register notification %s fail: method is defined by MCP", method)
+		return
+	}
+	server.notificationHandlers.Store(method, handler)
+}
@@ -22,10 +22,7 @@ func (server *Server) handleRequestWithInitialize(ctx context.Context, sessionID
 		return nil, err
 	}
 
-	if _, ok := protocol.SupportedVersion[request.ProtocolVersion]; !ok {
-		return nil, fmt.Errorf("protocol version not supported, supported lastest version is %v", protocol.Version)
-	}
-	protocolVersion := request.ProtocolVersion
+	protocolVersion := protocol.NegotiateVersion(request.ProtocolVersion)
 
 	if midVar, ok := ctx.Value(transport.SessionIDForReturnKey{}).(*transport.SessionIDForReturn); ok {
 		sessionID = server.sessionManager.CreateSession()
@@ -38,15 +35,18 @@ func (server *Server) handleRequestWithInitialize(ctx context.Context, sessionID
 			return nil, pkg.ErrLackSession
 		}
 		s.SetClientInfo(&request.ClientInfo, &request.Capabilities)
+		s.SetProtocolVersion(protocolVersion)
 		s.SetReceivedInitRequest()
 	}
 
-	return &protocol.InitializeResult{
+	result := &protocol.InitializeResult{
 		ServerInfo:      *server.serverInfo,
 		Capabilities:    *server.capabilities,
 		ProtocolVersion: protocolVersion,
 		Instructions:    server.instructions,
-	}, nil
+	}
+	// the session may only have been created above, so the result is adapted here rather than by receiveRequest
+	return protocol.AdaptToVersion(protocolVersion, result).(*protocol.InitializeResult), nil
 }
 
 func (server *Server) handleRequestWithListPrompts(rawParams json.RawMessage) (*protocol.ListPromptsResult, error) {
@@ -61,14 +61,21 @@ func (server *Server) handleRequestWithListPrompts(rawParams json.RawMessage) (*
 		}
 	}
 
-	prompts := make([]protocol.Prompt, 0)
-	server.prompts.Range(func(_ string, entry *promptEntry) bool {
-		prompts = append(prompts, *entry.prompt)
-		return true
+	var cursor string
+	if request != nil {
+		cursor = request.Cursor
+	}
+
+	prompts, nextCursor, err := listPage(&server.prompts, cursor, server.paginationLimit, nil, func(entry *promptEntry) protocol.Prompt {
+		return *entry.prompt
 	})
+	if err != nil {
+		return nil, err
+	}
 
 	return &protocol.ListPromptsResult{
-		Prompts: prompts,
+		Prompts:    prompts,
+		NextCursor: nextCursor,
 	}, nil
 }
 
@@ -86,7 +93,7 @@ func (server *Server) handleRequestWithGetPrompt(ctx context.Context, rawParams
 	if !ok {
 		return nil, fmt.Errorf("missing prompt, promptName=%s", request.Name)
 	}
-	return entry.handler(ctx, request)
+	return chain(entry.handler, server.promptMiddlewares)(ctx, request)
 }
 
 func (server *Server) handleRequestWithListResources(rawParams json.RawMessage) (*protocol.ListResourcesResult, error) {
@@ -101,14 +108,21 @@ func (server *Server) handleRequestWithListResources(rawParams json.RawMessage)
 		}
 	}
 
-	resources := make([]protocol.Resource, 0)
-	server.resources.Range(func(_ string, entry *resourceEntry) bool {
-		resources = append(resources, *entry.resource)
-		return true
+	var cursor string
+	if request != nil {
+		cursor = request.Cursor
+	}
+
+	resources, nextCursor, err := listPage(&server.resources, cursor, server.paginationLimit, nil, func(entry *resourceEntry) protocol.Resource {
+		return *entry.resource
 	})
+	if err != nil {
+		return nil, err
+	}
 
 	return &protocol.ListResourcesResult{
-		Resources: resources,
+		Resources:  resources,
+		NextCursor: nextCursor,
 	}, nil
 }
 
@@ -124,14 +138,22 @@ func (server *Server) handleRequestWithListResourceTemplates(rawParams json.RawM
 		}
 	}
 
-	templates := make([]protocol.ResourceTemplate, 0)
-	server.resourceTemplates.Range(func(_ string, entry *resourceTemplateEntry) bool {
-		templates = append(templates, *entry.resourceTemplate)
-		return true
-	})
+	var cursor string
+	if request != nil {
+		cursor = request.Cursor
+	}
+
+	templates, nextCursor, err := listPage(&server.resourceTemplates, cursor, server.paginationLimit, nil,
+		func(entry *resourceTemplateEntry) protocol.ResourceTemplate {
+			return *entry.resourceTemplate
+		})
+	if err != nil {
+		return nil, err
+	}
 
 	return &protocol.ListResourceTemplatesResult{
 		ResourceTemplates: templates,
+		NextCursor:        nextCursor,
 	}, nil
 }
 
@@ -166,7 +188,7 @@ func (server *Server) handleRequestWithReadResource(ctx context.Context, rawPara
 	if handler == nil {
 		return nil, fmt.Errorf("missing resource, resourceName=%s", request.URI)
 	}
-	return handler(ctx, request)
+	return chain(handler, server.resourceMiddlewares)(ctx, request)
 }
 
 func (server *Server) handleRequestWithSubscribeResourceChange(sessionID string, rawParams json.RawMessage) (*protocol.SubscribeResult, error) {
@@ -205,7 +227,7 @@ func (server *Server) handleRequestWithUnSubscribeResourceChange(sessionID strin
 	return protocol.NewUnsubscribeResult(), nil
 }
 
-func (server *Server) handleRequestWithListTools(rawParams json.RawMessage) (*protocol.ListToolsResult, error) {
+func (server *Server) handleRequestWithListTools(ctx context.Context, rawParams json.RawMessage) (*protocol.ListToolsResult, error) {
 	if server.capabilities.Tools == nil {
 		return nil, pkg.ErrServerNotSupport
 	}
@@ -217,13 +239,22 @@ func (server *Server) handleRequestWithListTools(rawParams json.RawMessage) (*pr
 		}
 	}
 
-	tools := make([]*protocol.Tool, 0)
-	server.tools.Range(func(_ string, entry *toolEntry) bool {
-		tools = append(tools, entry.tool)
-		return true
+	var cursor string
+	if request != nil {
+		cursor = request.Cursor
+	}
+
+	keep := func(entry *toolEntry) bool {
+		return server.toolVisible(ctx, entry.tool)
+	}
+	tools, nextCursor, err := listPage(&server.tools, cursor, server.paginationLimit, keep, func(entry *toolEntry) *protocol.Tool {
+		return entry.tool
 	})
+	if err != nil {
+		return nil, err
+	}
 
-	return &protocol.ListToolsResult{Tools: tools}, nil
+	return &protocol.ListToolsResult{Tools: tools, NextCursor: nextCursor}, nil
 }
 
 func (server *Server) handleRequestWithCallTool(ctx context.Context, rawParams json.RawMessage) (*protocol.CallToolResult, error) {
@@ -237,11 +268,73 @@ func (server *Server) handleRequestWithCallTool(ctx context.Context, rawParams j
 	}
 
 	entry, ok := server.tools.Load(request.Name)
-	if !ok {
+	if !ok || !server.toolVisible(ctx, entry.tool) {
 		return nil, fmt.Errorf("missing tool, toolName=%s", request.Name)
 	}
 
-	return entry.handler(ctx, request)
+	if err := entry.validateArguments(request.RawArguments); err != nil {
+		return nil, err
+	}
+
+	result, err := chain(entry.handler, server.toolMiddlewares)(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	if entry.tool.HasOutputSchema() && result != nil && !result.IsError {
+		if result.StructuredContent == nil {
+			return nil, fmt.Errorf("tool %s declares an output schema but returned no structured content", request.Name)
+		}
+		if err = entry.verifyStructuredContent(result.StructuredContent); err != nil {
+			return nil, fmt.Errorf("tool %s returned invalid structured content: %w", request.Name, err)
+		}
+	}
+	return result, nil
+}
+
+func (server *Server) handleRequestWithSetLoggingLevel(sessionID string, rawParams json.RawMessage) (*protocol.SetLoggingLevelResult, error) {
+	if server.capabilities.Logging == nil {
+		return nil, pkg.ErrServerNotSupport
+	}
+
+	var request *protocol.SetLoggingLevelRequest
+	if err := pkg.JSONUnmarshal(rawParams, &request); err != nil {
+		return nil, err
+	}
+
+	if !request.Level.IsValid() {
+		return nil, fmt.Errorf("%w: unknown logging level %q", pkg.ErrInvalidParams, request.Level)
+	}
+
+	s, ok := server.sessionManager.GetSession(sessionID)
+	if !ok {
+		return nil, pkg.ErrLackSession
+	}
+	s.SetLoggingLevel(request.Level)
+	return &protocol.SetLoggingLevelResult{}, nil
+}
+
+func (server *Server) handleRequestWithComplete(ctx context.Context, rawParams json.RawMessage) (*protocol.CompleteResult, error) {
+	if server.capabilities.Completions == nil {
+		return nil, pkg.ErrServerNotSupport
+	}
+
+	var request *protocol.CompleteRequest
+	if err := pkg.JSONUnmarshal(rawParams, &request); err != nil {
+		return nil, err
+	}
+
+	key, err := completionRefKey(request.Ref)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", pkg.ErrInvalidParams, err)
+	}
+
+	handler, ok := server.completions.Load(key)
+	if !ok {
+		// Nothing to suggest for this argument
+		return protocol.NewCompleteResult([]string{}, false, 0), nil
+	}
+	return handler(ctx, request)
 }
 
 func (server *Server) handleNotifyWithInitialized(sessionID string, rawParams json.RawMessage) error {
@@ -268,6 +361,47 @@ func (server *Server) handleNotifyWithInitialized(sessionID string, rawParams js
 	return nil
 }
 
+func (server *Server) handleNotifyWithCancelled(sessionID string, rawParams json.RawMessage) error {
+	notify := &protocol.CancelledNotification{}
+	if err := pkg.JSONUnmarshal(rawParams, notify); err != nil {
+		return err
+	}
+
+	s, ok := server.sessionManager.GetSession(sessionID)
+	if !ok {
+		// Requests of a stateless server are not tracked and can't be canceled
+		return nil
+	}
+
+	// The request may have already finished, in which case the notification is ignored.
+	if cancel, ok := s.GetInFlightRequests().Get(fmt.Sprint(notify.RequestID)); ok {
+		server.logger.Infof("cancel request: sessionID=%s, requestID=%v, reason=%s", sessionID, notify.RequestID, notify.Reason)
+		cancel()
+	}
+	return nil
+}
+
+func (server *Server) handleNotifyWithRootsListChanged(sessionID string, rawParams json.RawMessage) error {
+	notify := &protocol.RootsListChangedNotification{}
+	if len(rawParams) > 0 {
+		if err := pkg.JSONUnmarshal(rawParams, notify); err != nil {
+			return err
+		}
+	}
+
+	if server.rootsListChangedHandler == nil {
+		return nil
+	}
+
+	// The hook usually calls back into the client, it must not block the transport's receive loop.
+	go func() {
+		defer pkg.Recover()
+
+		server.rootsListChangedHandler(setSessionIDToCtx(context.Background(), sessionID), sessionID)
+	}()
+	return nil
+}
+
 func matchesTemplate(uri string, template *uritemplate.Template) bool {
 	return template.Regexp().MatchString(uri)
 }
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+)
+
+// Request is a client request as seen by a Middleware
+type Request struct {
+	Method    protocol.Method
+	SessionID string
+	RawParams json.RawMessage
+}
+
+// Handler handles a client request, the result is sent back to the client as the JSON-RPC result
+type Handler func(ctx context.Context, request *Request) (protocol.ServerResponse, error)
+
+// Middleware wraps the handling of every client request
+type Middleware func(next Handler) Handler
+
+// ToolMiddleware wraps every tool handler
+type ToolMiddleware func(next ToolHandlerFunc) ToolHandlerFunc
+
+// PromptMiddleware wraps every prompt handler
+type PromptMiddleware func(next PromptHandlerFunc) PromptHandlerFunc
+
+// ResourceMiddleware wraps every resource and resource template handler
+type ResourceMiddleware func(next ResourceHandlerFunc) ResourceHandlerFunc
+
+// WithMiddleware adds middlewares around the handling of every client request,
+// the first middleware added is the outermost one.
+func WithMiddleware(middlewares ...Middleware) Option {
+	return func(s *Server) {
+		s.middlewares = append(s.middlewares, middlewares...)
+	}
+}
+
+// WithToolMiddleware adds middlewares around every tool handler, the first middleware added is the outermost one.
+func WithToolMiddleware(middlewares ...ToolMiddleware) Option {
+	return func(s *Server) {
+		s.toolMiddlewares = append(s.toolMiddlewares, middlewares...)
+	}
+}
+
+// WithPromptMiddleware adds middlewares around every prompt handler, the first middleware added is the outermost one.
+func WithPromptMiddleware(middlewares ...PromptMiddleware) Option {
+	return func(s *Server) {
+		s.promptMiddlewares = append(s.promptMiddlewares, middlewares...)
+	}
+}
+
+// WithResourceMiddleware adds middlewares around every resource handler, the first middleware added is the outermost one.
+func WithResourceMiddleware(middlewares ...ResourceMiddleware) Option {
+	return func(s *Server) {
+		s.resourceMiddlewares = append(s.resourceMiddlewares, middlewares...)
+	}
+}
+
+// chain wraps h with middlewares, so that middlewares[0] is called first
+func chain[H any, M ~func(H) H](h H, middlewares []M) H {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
+	}
+	return h
+}
@@ -0,0 +1,46 @@
+package server
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+
+	"github.com/ThinkInAIXYZ/go-mcp/pkg"
+)
+
+// listPage returns the entries of m sorted by key, starting after cursor and holding at most limit entries (0 means no limit).
+// The cursor is the encoded key of the last entry of the previous page, so it stays valid when entries are registered or removed.
+// Entries for which keep returns false are skipped before paging, a nil keep keeps everything.
+func listPage[E any, T any](m *pkg.SyncMap[E], cursor string, limit int, keep func(E) bool, convert func(E) T) ([]T, string, error) {
+	var after string
+	if cursor != "" {
+		b, err := base64.RawURLEncoding.DecodeString(cursor)
+		if err != nil {
+			return nil, "", fmt.Errorf("%w: invalid cursor %q", pkg.ErrInvalidParams, cursor)
+		}
+		after = string(b)
+	}
+
+	keys := make([]string, 0)
+	entries := make(map[string]E)
+	m.Range(func(key string, entry E) bool {
+		if (cursor == "" || key > after) && (keep == nil || keep(entry)) {
+			keys = append(keys, key)
+			entries[key] = entry
+		}
+		return true
+	})
+	sort.Strings(keys)
+
+	var nextCursor string
+	if limit > 0 && len(keys) > limit {
+		keys = keys[:limit]
+		nextCursor = base64.RawURLEncoding.EncodeToString([]byte(keys[limit-1]))
+	}
+
+	items := make([]T, 0, len(keys))
+	for _, key := range keys {
+		items = append(items, convert(entries[key]))
+	}
+	return items, nextCursor, nil
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/ThinkInAIXYZ/go-mcp/pkg"
 	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+	"github.com/ThinkInAIXYZ/go-mcp/transport"
 )
 
 func (server *Server) receive(ctx context.Context, sessionID string, msg []byte) (<-chan []byte, error) {
@@ -20,6 +21,89 @@ func (server *Server) receive(ctx context.Context, sessionID string, msg []byte)
 		return nil, pkg.ErrLackSession
 	}
 
+	if gjson.ParseBytes(msg).IsArray() {
+		return server.receiveBatch(ctx, sessionID, msg)
+	}
+	return server.receiveMessage(ctx, sessionID, msg)
+}
+
+// receiveBatch handles a JSON-RPC batch: its requests are handled concurrently,
+// and their responses are returned as one array in the order of the requests.
+// Batching was removed in 2025-06-18, sessions negotiated at that version or later get an InvalidRequest error.
+func (server *Server) receiveBatch(ctx context.Context, sessionID string, msg []byte) (<-chan []byte, error) {
+	if s, ok := server.sessionManager.GetSession(sessionID); ok && !protocol.VersionBefore(s.GetProtocolVersion(), protocol.Version20250618) {
+		message, err := json.Marshal(protocol.NewJSONRPCErrorResponse(nil, protocol.InvalidRequest,
+			fmt.Sprintf("%s: batch is not supported by protocol version %s", pkg.ErrRequestInvalid, s.GetProtocolVersion())))
+		if err != nil {
+			return nil, err
+		}
+		ch := make(chan []byte, 1)
+		ch <- message
+		close(ch)
+		return ch, nil
+	}
+
+	var batch []json.RawMessage
+	if err := pkg.JSONUnmarshal(msg, &batch); err != nil {
+		return nil, err
+	}
+	if len(batch) == 0 {
+		return nil, fmt.Errorf("%w: empty batch", pkg.ErrRequestInvalid)
+	}
+
+	outputChs := make([]<-chan []byte, 0, len(batch))
+	for _, item := range batch {
+		outputCh, err := server.receiveMessage(ctx, sessionID, item)
+		if err != nil {
+			id := gjson.GetBytes(item, "id")
+			if !id.Exists() || !gjson.GetBytes(item, "method").Exists() {
+				server.logger.Errorf("receive batch item:%s error: %s", item, err.Error())
+				continue
+			}
+			// a failed request is answered inside the batch instead of failing the whole batch
+			errCh := make(chan []byte, 1)
+			message, e := json.Marshal(protocol.NewJSONRPCErrorResponse(id.Value(), errorCode(err), err.Error()))
+			if e != nil {
+				return nil, e
+			}
+			errCh <- message
+			outputCh = errCh
+		}
+		if outputCh != nil {
+			outputChs = append(outputChs, outputCh)
+		}
+	}
+
+	if len(outputChs) == 0 {
+		return nil, nil
+	}
+
+	ch := make(chan []byte, 1)
+	go func() {
+		defer pkg.Recover()
+		defer close(ch)
+
+		responses := make([]json.RawMessage, 0, len(outputChs))
+		for _, outputCh := range outputChs {
+			if message := <-outputCh; len(message) > 0 {
+				responses = append(responses, message)
+			}
+		}
+		if len(responses) == 0 {
+			return
+		}
+
+		message, err := json.Marshal(responses)
+		if err != nil {
+			server.logger.Errorf("receive json marshal batch response error: %s", err.Error())
+			return
+		}
+		ch <- message
+	}()
+	return ch, nil
+}
+
+func (server *Server) receiveMessage(ctx context.Context, sessionID string, msg []byte) (<-chan []byte, error) {
 	if !gjson.GetBytes(msg, "id").Exists() {
 		notify := &protocol.JSONRPCNotification{}
 		if err := pkg.JSONUnmarshal(msg, &notify); err != nil {
@@ -71,30 +155,71 @@ func (server *Server) receive(ctx context.Context, sessionID string, msg []byte)
 		return nil, errors.New("server already shutdown")
 	}
 
+	// The transport's ctx is shielded, the handler's ctx is canceled only by the client's notifications/cancelled.
+	ctx, cancel := context.WithCancel(pkg.NewCancelShieldContext(ctx))
+	requestKey := fmt.Sprint(req.ID)
+	s, tracked := server.sessionManager.GetSession(sessionID)
+	if tracked {
+		s.GetInFlightRequests().Set(requestKey, cancel)
+	}
+
 	ch := make(chan []byte, 1)
 	go func(ctx context.Context) {
 		defer pkg.Recover()
 		defer server.inFlyRequest.Done()
 		defer close(ch)
+		defer cancel()
+		if tracked {
+			defer s.GetInFlightRequests().Remove(requestKey)
+		}
 
 		resp := server.receiveRequest(ctx, sessionID, req)
+		if ctx.Err() != nil {
+			// The client has canceled the request, no response should be sent.
+			server.logger.Infof("request canceled by client: sessionID=%s, requestID=%v", sessionID, req.ID)
+			if canceled, ok := ctx.Value(transport.RequestCanceledKey{}).(*transport.RequestCanceled); ok {
+				canceled.Cancel()
+			}
+			return
+		}
+
 		message, err := json.Marshal(resp)
 		if err != nil {
 			server.logger.Errorf("receive json marshal response:%+v error: %s", resp, err.Error())
 			return
 		}
 		ch <- message
-	}(pkg.NewCancelShieldContext(ctx))
+	}(ctx)
 	return ch, nil
 }
 
 func (server *Server) receiveRequest(ctx context.Context, sessionID string, request *protocol.JSONRPCRequest) *protocol.JSONRPCResponse {
 	ctx = setSessionIDToCtx(ctx, sessionID)
+	if meta := gjson.GetBytes(request.RawParams, "_meta"); meta.IsObject() {
+		if m, ok := meta.Value().(map[string]interface{}); ok {
+			ctx = setMetaToCtx(ctx, m)
+		}
+	}
+	if token := gjson.GetBytes(request.RawParams, "_meta."+protocol.ProgressTokenMetaKey); token.Exists() {
+		// keep the raw token, so that it is echoed back to the client exactly as it was sent
+		ctx = setProgressTokenToCtx(ctx, json.RawMessage(token.Raw))
+	}
 
 	if request.Method != protocol.Ping {
 		server.sessionManager.UpdateSessionLastActiveAt(sessionID)
 	}
 
+	result, err := server.requestHandler(ctx, &Request{Method: request.Method, SessionID: sessionID, RawParams: request.RawParams})
+	if err != nil {
+		return protocol.NewJSONRPCErrorResponse(request.ID, errorCode(err), err.Error())
+	}
+	return protocol.NewJSONRPCSuccessResponse(request.ID, server.adaptToSession(sessionID, result))
+}
+
+// handleRequest dispatches a client request to its handler, it is the innermost Handler of the middleware chain
+func (server *Server) handleRequest(ctx context.Context, request *Request) (protocol.ServerResponse, error) {
+	sessionID := request.SessionID
+
 	var (
 		result protocol.ServerResponse
 		err    error
@@ -120,28 +245,39 @@ func (server *Server) receiveRequest(ctx context.Context, sessionID string, requ
 	case protocol.ResourcesUnsubscribe:
 		result, err = server.handleRequestWithUnSubscribeResourceChange(sessionID, request.RawParams)
 	case protocol.ToolsList:
-		result, err = server.handleRequestWithListTools(request.RawParams)
+		result, err = server.handleRequestWithListTools(ctx, request.RawParams)
 	case protocol.ToolsCall:
 		result, err = server.handleRequestWithCallTool(ctx, request.RawParams)
+	case protocol.CompletionComplete:
+		result, err = server.handleRequestWithComplete(ctx, request.RawParams)
+	case protocol.LoggingSetLevel:
+		result, err = server.handleRequestWithSetLoggingLevel(sessionID, request.RawParams)
 	default:
-		err = fmt.Errorf("%w: method=%s", pkg.ErrMethodNotSupport, request.Method)
+		handler, ok := server.methodHandlers.Load(string(request.Method))
+		if !ok {
+			err = fmt.Errorf("%w: method=%s", pkg.ErrMethodNotSupport, request.Method)
+			break
+		}
+		result, err = handler(ctx, request.RawParams)
 	}
 
-	if err != nil {
-		var code int
-		switch {
-		case errors.Is(err, pkg.ErrMethodNotSupport):
-			code = protocol.MethodNotFound
-		case errors.Is(err, pkg.ErrRequestInvalid):
-			code = protocol.InvalidRequest
-		case errors.Is(err, pkg.ErrJSONUnmarshal):
-			code = protocol.ParseError
-		default:
-			code = protocol.InternalError
-		}
-		return protocol.NewJSONRPCErrorResponse(request.ID, code, err.Error())
-	}
-	return protocol.NewJSONRPCSuccessResponse(request.ID, result)
+	return result, err
+}
+
+// errorCode maps the error of a request to its JSON-RPC error code
+func errorCode(err error) int {
+	switch {
+	case errors.Is(err, pkg.ErrMethodNotSupport):
+		return protocol.MethodNotFound
+	case errors.Is(err, pkg.ErrRequestInvalid):
+		return protocol.InvalidRequest
+	case errors.Is(err, pkg.ErrInvalidParams):
+		return protocol.InvalidParams
+	case errors.Is(err, pkg.ErrJSONUnmarshal):
+		return protocol.ParseError
+	default:
+		return protocol.InternalError
+	}
 }
 
 func (server *Server) receiveNotify(sessionID string, notify *protocol.JSONRPCNotification) error {
@@ -156,8 +292,16 @@ func (server *Server) receiveNotify(sessionID string, notify *protocol.JSONRPCNo
 	switch notify.Method {
 	case protocol.NotificationInitialized:
 		return server.handleNotifyWithInitialized(sessionID, notify.RawParams)
+	case protocol.NotificationCancelled:
+		return server.handleNotifyWithCancelled(sessionID, notify.RawParams)
+	case protocol.NotificationRootsListChanged:
+		return server.handleNotifyWithRootsListChanged(sessionID, notify.RawParams)
 	default:
-		return fmt.Errorf("%w: method=%s", pkg.ErrMethodNotSupport, notify.Method)
+		handler, ok := server.notificationHandlers.Load(string(notify.Method))
+		if !ok {
+			return fmt.Errorf("%w: method=%s", pkg.ErrMethodNotSupport, notify.Method)
+		}
+		return handler(setSessionIDToCtx(context.Background(), sessionID), notify.RawParams)
 	}
 }
 
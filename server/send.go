@@ -15,7 +15,7 @@ func (server *Server) sendMsgWithRequest(ctx context.Context, sessionID string,
 		return fmt.Errorf("requestID can't is nil")
 	}
 
-	req := protocol.NewJSONRPCRequest(requestID, method, params)
+	req := protocol.NewJSONRPCRequest(requestID, method, server.adaptToSession(sessionID, params))
 
 	message, err := json.Marshal(req)
 	if err != nil {
@@ -28,8 +28,17 @@ func (server *Server) sendMsgWithRequest(ctx context.Context, sessionID string,
 	return nil
 }
 
+// adaptToSession removes the fields of msg that the protocol version negotiated with the session doesn't know about
+func (server *Server) adaptToSession(sessionID string, msg interface{}) interface{} {
+	s, ok := server.sessionManager.GetSession(sessionID)
+	if !ok {
+		return msg
+	}
+	return protocol.AdaptToVersion(s.GetProtocolVersion(), msg)
+}
+
 func (server *Server) sendMsgWithNotification(ctx context.Context, sessionID string, method protocol.Method, params protocol.ServerNotify) error {
-	notify := protocol.NewJSONRPCNotification(method, params)
+	notify := protocol.NewJSONRPCNotification(method, server.adaptToSession(sessionID, params))
 
 	message, err := json.Marshal(notify)
 	if err != nil {
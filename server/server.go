@@ -2,10 +2,14 @@ package server
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
 
+	"github.com/tidwall/gjson"
+
 	"github.com/ThinkInAIXYZ/go-mcp/pkg"
 	"github.com/ThinkInAIXYZ/go-mcp/protocol"
 	"github.com/ThinkInAIXYZ/go-mcp/server/session"
@@ -38,12 +42,38 @@ func WithSessionMaxIdleTime(maxIdleTime time.Duration) Option {
 	}
 }
 
+// WithPaginationLimit sets the maximum number of tools, prompts, resources or resource templates returned by one list request,
+// 0 (the default) returns everything in a single page.
+func WithPaginationLimit(limit int) Option {
+	return func(s *Server) {
+		s.paginationLimit = limit
+	}
+}
+
+// WithToolFilter sets a filter deciding which tools each session can see,
+// filtered tools are left out of tools/list and cannot be called.
+func WithToolFilter(filter ToolFilter) Option {
+	return func(s *Server) {
+		s.toolFilter = filter
+	}
+}
+
 func WithLogger(logger pkg.Logger) Option {
 	return func(s *Server) {
 		s.logger = logger
 	}
 }
 
+// WithRootsListChangedHandler sets the hook called when a client reports that its roots have changed,
+// the ctx carries the session, so the hook can call Server.ListRoots to fetch the new roots.
+func WithRootsListChangedHandler(handler RootsListChangedHandler) Option {
+	return func(s *Server) {
+		s.rootsListChangedHandler = handler
+	}
+}
+
+type RootsListChangedHandler func(ctx context.Context, sessionID string)
+
 type Server struct {
 	transport transport.ServerTransport
 
@@ -51,16 +81,35 @@ type Server struct {
 	prompts           pkg.SyncMap[*promptEntry]
 	resources         pkg.SyncMap[*resourceEntry]
 	resourceTemplates pkg.SyncMap[*resourceTemplateEntry]
+	completions       pkg.SyncMap[CompletionHandlerFunc]
+
+	methodHandlers       pkg.SyncMap[MethodHandlerFunc]
+	notificationHandlers pkg.SyncMap[NotificationHandlerFunc]
 
 	sessionManager *session.Manager
 
+	rootsListChangedHandler RootsListChangedHandler
+
 	inShutdown   *pkg.AtomicBool // true when server is in shutdown
 	inFlyRequest sync.WaitGroup
 
 	capabilities *protocol.ServerCapabilities
+	experimental map[string]interface{}
 	serverInfo   *protocol.Implementation
 	instructions string
 
+	paginationLimit int
+
+	toolFilter ToolFilter
+
+	middlewares         []Middleware
+	toolMiddlewares     []ToolMiddleware
+	promptMiddlewares   []PromptMiddleware
+	resourceMiddlewares []ResourceMiddleware
+
+	// requestHandler is handleRequest wrapped by the middlewares
+	requestHandler Handler
+
 	logger pkg.Logger
 }
 
@@ -68,9 +117,11 @@ func NewServer(t transport.ServerTransport, opts ...Option) (*Server, error) {
 	server := &Server{
 		transport: t,
 		capabilities: &protocol.ServerCapabilities{
-			Prompts:   &protocol.PromptsCapability{ListChanged: true},
-			Resources: &protocol.ResourcesCapability{ListChanged: true, Subscribe: true},
-			Tools:     &protocol.ToolsCapability{ListChanged: true},
+			Logging:     struct{}{},
+			Completions: struct{}{},
+			Prompts:     &protocol.PromptsCapability{ListChanged: true},
+			Resources:   &protocol.ResourcesCapability{ListChanged: true, Subscribe: true},
+			Tools:       &protocol.ToolsCapability{ListChanged: true},
 		},
 		inShutdown: pkg.NewAtomicBool(),
 		serverInfo: &protocol.Implementation{},
@@ -85,6 +136,19 @@ func NewServer(t transport.ServerTransport, opts ...Option) (*Server, error) {
 		opt(server)
 	}
 
+	if len(server.experimental) > 0 {
+		experimental := make(map[string]interface{}, len(server.capabilities.Experimental)+len(server.experimental))
+		for name, settings := range server.capabilities.Experimental {
+			experimental[name] = settings
+		}
+		for name, settings := range server.experimental {
+			experimental[name] = settings
+		}
+		server.capabilities.Experimental = experimental
+	}
+
+	server.requestHandler = chain(server.handleRequest, server.middlewares)
+
 	server.sessionManager.SetLogger(server.logger)
 
 	t.SetSessionManager(server.sessionManager)
@@ -108,16 +172,116 @@ func (server *Server) Run() error {
 type toolEntry struct {
 	tool    *protocol.Tool
 	handler ToolHandlerFunc
+
+	// inputSchema and outputSchema are compiled when the tool is registered,
+	// either is nil when the tool has no such schema or it failed to compile, and no validation against it is done
+	inputSchema  *protocol.CompiledSchema
+	outputSchema *protocol.CompiledSchema
+}
+
+// verifyStructuredContent checks the structured content of a result against the output schema of the tool
+func (entry *toolEntry) verifyStructuredContent(structuredContent interface{}) error {
+	if entry.outputSchema == nil {
+		return nil
+	}
+	content, err := json.Marshal(structuredContent)
+	if err != nil {
+		return err
+	}
+	if err = entry.outputSchema.ValidateJSON(content); err != nil {
+		return fmt.Errorf("structured content validation failed against the output schema: %w", err)
+	}
+	return nil
+}
+
+// validateArguments checks the arguments of a tools/call against the input schema of the tool
+func (entry *toolEntry) validateArguments(arguments json.RawMessage) error {
+	if entry.inputSchema == nil {
+		return nil
+	}
+	if len(arguments) == 0 {
+		arguments = json.RawMessage("{}")
+	}
+	if err := entry.inputSchema.ValidateJSON(arguments); err != nil {
+		return fmt.Errorf("%w: tool %s arguments: %v", pkg.ErrInvalidParams, entry.tool.Name, err)
+	}
+	return nil
 }
 
 type ToolHandlerFunc func(context.Context, *protocol.CallToolRequest) (*protocol.CallToolResult, error)
 
+// ToolFilter reports whether tool is visible to the session carried by ctx
+type ToolFilter func(ctx context.Context, tool *protocol.Tool) bool
+
+// HideDestructiveTools returns a ToolFilter that hides tools which may perform destructive updates
+// (see protocol.ToolAnnotations.IsDestructive) from every session for which allowed returns false.
+// As the spec defaults destructiveHint to true, a tool without annotations counts as destructive and is hidden too,
+// annotate a tool with WithReadOnlyHint(true) or WithDestructiveHint(false) to keep it visible.
+func HideDestructiveTools(allowed func(sessionID string) bool) ToolFilter {
+	return func(ctx context.Context, tool *protocol.Tool) bool {
+		if !tool.Annotations.IsDestructive() {
+			return true
+		}
+		sessionID, err := getSessionIDFromCtx(ctx)
+		if err != nil {
+			return false
+		}
+		return allowed(sessionID)
+	}
+}
+
+func (server *Server) toolVisible(ctx context.Context, tool *protocol.Tool) bool {
+	return server.toolFilter == nil || server.toolFilter(ctx, tool)
+}
+
+// RegisterTool registers a tool, the arguments of every call are validated against its input schema before toolHandler is called.
+// A tool whose input or output schema doesn't compile is still registered with a warning, but its calls skip the validation
+// against that schema. Use TryRegisterTool to reject such a tool instead.
 func (server *Server) RegisterTool(tool *protocol.Tool, toolHandler ToolHandlerFunc) {
-	server.tools.Store(tool.Name, &toolEntry{tool: tool, handler: toolHandler})
+	entry, err := newToolEntry(tool, toolHandler)
+	if err != nil {
+		server.logger.Warnf("register tool %s without schema validation: %v", tool.Name, err)
+	}
+	server.storeTool(entry)
+}
+
+// TryRegisterTool registers a tool like RegisterTool does, but returns an error instead of registering a tool whose input or output schema doesn't compile.
+func (server *Server) TryRegisterTool(tool *protocol.Tool, toolHandler ToolHandlerFunc) error {
+	entry, err := newToolEntry(tool, toolHandler)
+	if err != nil {
+		return err
+	}
+	server.storeTool(entry)
+	return nil
+}
+
+// newToolEntry compiles the schemas of tool, the returned entry is always usable and lacks any schema that failed to compile
+func newToolEntry(tool *protocol.Tool, toolHandler ToolHandlerFunc) (*toolEntry, error) {
+	entry := &toolEntry{tool: tool, handler: toolHandler}
+
+	var errs []error
+	inputSchema, err := tool.CompileInputSchema()
+	if err != nil {
+		errs = append(errs, fmt.Errorf("tool %s has an invalid input schema: %w", tool.Name, err))
+	} else {
+		entry.inputSchema = inputSchema
+	}
+	if tool.HasOutputSchema() {
+		outputSchema, err := tool.CompileOutputSchema()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("tool %s has an invalid output schema: %w", tool.Name, err))
+		} else {
+			entry.outputSchema = outputSchema
+		}
+	}
+	return entry, pkg.JoinErrors(errs)
+}
+
+func (server *Server) storeTool(entry *toolEntry) {
+	server.tools.Store(entry.tool.Name, entry)
 	if !server.sessionManager.IsEmpty() {
 		if err := server.sendNotification4ToolListChanges(context.Background()); err != nil {
 			server.logger.Warnf("send notification toll list changes fail: %v", err)
-			return
 		}
 	}
 }
@@ -215,6 +379,47 @@ func (server *Server) UnregisterResourceTemplate(uriTemplate string) {
 	}
 }
 
+type CompletionHandlerFunc func(context.Context, *protocol.CompleteRequest) (*protocol.CompleteResult, error)
+
+// RegisterCompletionHandler registers the argument completion for a prompt or resource,
+// ref must be a protocol.PromptReference or protocol.ResourceReference.
+func (server *Server) RegisterCompletionHandler(ref interface{}, handler CompletionHandlerFunc) error {
+	key, err := completionRefKey(ref)
+	if err != nil {
+		return err
+	}
+	server.completions.Store(key, handler)
+	return nil
+}
+
+func (server *Server) UnregisterCompletionHandler(ref interface{}) error {
+	key, err := completionRefKey(ref)
+	if err != nil {
+		return err
+	}
+	server.completions.Delete(key)
+	return nil
+}
+
+func completionRefKey(ref interface{}) (string, error) {
+	switch r := ref.(type) {
+	case protocol.PromptReference:
+		return protocol.PromptReferenceType + ":" + r.Name, nil
+	case *protocol.PromptReference:
+		return protocol.PromptReferenceType + ":" + r.Name, nil
+	case protocol.ResourceReference:
+		return protocol.ResourceReferenceType + ":" + r.URI, nil
+	case *protocol.ResourceReference:
+		return protocol.ResourceReferenceType + ":" + r.URI, nil
+	case nil:
+		return "", errors.New("reference is missing")
+	case json.RawMessage:
+		return "", fmt.Errorf("unknown reference type %q", gjson.GetBytes(r, "type").String())
+	default:
+		return "", fmt.Errorf("unsupported completion reference type %T", ref)
+	}
+}
+
 func (server *Server) Shutdown(userCtx context.Context) error {
 	server.inShutdown.Store(true)
 
@@ -2,11 +2,19 @@ package server
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -124,6 +132,16 @@ func TestServerHandle(t *testing.T) {
 		return
 	}
 
+	// add completion
+	testCompleteResult := protocol.NewCompleteResult([]string{"go-mcp", "go-sdk"}, false, 2)
+	if err := server.RegisterCompletionHandler(protocol.NewPromptReference(testPrompt.Name),
+		func(context.Context, *protocol.CompleteRequest) (*protocol.CompleteResult, error) {
+			return testCompleteResult, nil
+		}); err != nil {
+		t.Fatalf("RegisterCompletionHandler: %+v", err)
+		return
+	}
+
 	go func() {
 		if err := server.Run(); err != nil {
 			t.Errorf("server start: %+v", err)
@@ -148,7 +166,8 @@ func TestServerHandle(t *testing.T) {
 			name:   "test_call_tool",
 			method: protocol.ToolsCall,
 			request: protocol.CallToolRequest{
-				Name: testTool.Name,
+				Name:      testTool.Name,
+				Arguments: map[string]interface{}{"timezone": "UTC"},
 			},
 			expectedResponse: protocol.CallToolResult{
 				Content: []protocol.Content{
@@ -220,6 +239,24 @@ func TestServerHandle(t *testing.T) {
 			},
 			expectedResponse: protocol.UnsubscribeResult{},
 		},
+		{
+			name:             "test_set_logging_level",
+			method:           protocol.LoggingSetLevel,
+			request:          protocol.SetLoggingLevelRequest{Level: protocol.LogWarning},
+			expectedResponse: protocol.SetLoggingLevelResult{},
+		},
+		{
+			name:             "test_complete",
+			method:           protocol.CompletionComplete,
+			request:          protocol.NewCompleteRequest("params1", "go", protocol.NewPromptReference(testPrompt.Name)),
+			expectedResponse: testCompleteResult,
+		},
+		{
+			name:             "test_complete_without_handler",
+			method:           protocol.CompletionComplete,
+			request:          protocol.NewCompleteRequest("uri", "file", protocol.NewResourceReference(testResource.URI)),
+			expectedResponse: protocol.NewCompleteResult([]string{}, false, 0),
+		},
 	}
 
 	for _, tt := range tests {
@@ -411,6 +448,19 @@ func TestServerNotify(t *testing.T) {
 			},
 			expectedNotify: protocol.NewResourceListChangedNotification(),
 		},
+		{
+			name:   "test_log_message_notify",
+			method: protocol.NotificationLogMessage,
+			f: func() {
+				if err := server.Log(context.Background(), protocol.LogDebug, "test_logger", "filtered by level"); err != nil {
+					t.Fatalf("Log: %+v", err)
+				}
+				if err := server.Log(context.Background(), protocol.LogError, "test_logger", "something failed"); err != nil {
+					t.Fatalf("Log: %+v", err)
+				}
+			},
+			expectedNotify: protocol.NewLogMessageNotificationWithData(protocol.LogError, "test_logger", "something failed"),
+		},
 	}
 
 	for _, tt := range tests {
@@ -455,9 +505,257 @@ func TestServerNotify(t *testing.T) {
 	}
 }
 
+func TestServerReportProgress(t *testing.T) {
+	reader1, writer1 := io.Pipe()
+	reader2, writer2 := io.Pipe()
+
+	var (
+		in = struct {
+			reader io.ReadCloser
+			writer io.WriteCloser
+		}{
+			reader: reader1,
+			writer: writer1,
+		}
+
+		out = struct {
+			reader io.ReadCloser
+			writer io.WriteCloser
+		}{
+			reader: reader2,
+			writer: writer2,
+		}
+
+		outScan = bufio.NewScanner(out.reader)
+	)
+
+	server, err := NewServer(transport.NewMockServerTransport(in.reader, out.writer))
+	if err != nil {
+		t.Fatalf("NewServer: %+v", err)
+	}
+
+	testTool, err := protocol.NewTool("test_tool", "test_tool", currentTimeReq{})
+	if err != nil {
+		t.Fatalf("NewTool: %+v", err)
+		return
+	}
+	server.RegisterTool(testTool, func(ctx context.Context, _ *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+		if err := server.ReportProgress(ctx, 1, 2, "half way"); err != nil {
+			return nil, err
+		}
+		return protocol.NewCallToolResult([]protocol.Content{}, false), nil
+	})
+
+	go func() {
+		if err := server.Run(); err != nil {
+			t.Errorf("server start: %+v", err)
+		}
+	}()
+
+	testServerInit(t, server, in.writer, outScan)
+
+	request := protocol.NewCallToolRequest(testTool.Name, map[string]interface{}{"timezone": "UTC"})
+	request.Meta = map[string]interface{}{protocol.ProgressTokenMetaKey: "token-1"}
+	reqBytes, err := json.Marshal(protocol.NewJSONRPCRequest(uuid.NewString(), protocol.ToolsCall, request))
+	if err != nil {
+		t.Fatalf("json Marshal: %+v", err)
+	}
+	if _, err = in.writer.Write(append(reqBytes, "\n"...)); err != nil {
+		t.Fatalf("in Write: %+v", err)
+	}
+
+	var notifyBytes []byte
+	if outScan.Scan() {
+		notifyBytes = outScan.Bytes()
+	}
+	notify := &protocol.JSONRPCNotification{}
+	if err = pkg.JSONUnmarshal(notifyBytes, notify); err != nil {
+		t.Fatal(err)
+	}
+	if notify.Method != protocol.NotificationProgress {
+		t.Fatalf("notify method not as expected: %s", notify.Method)
+	}
+	progress := &protocol.ProgressNotification{}
+	if err = pkg.JSONUnmarshal(notify.RawParams, progress); err != nil {
+		t.Fatal(err)
+	}
+	expectedProgress := protocol.NewProgressNotificationWithMessage("token-1", 1, 2, "half way")
+	if !reflect.DeepEqual(progress, expectedProgress) {
+		t.Fatalf("progress not as expected.\ngot  = %+v\nwant = %+v", progress, expectedProgress)
+	}
+
+	if !outScan.Scan() { // tools/call response
+		t.Fatalf("outScan: %+v", outScan.Err())
+	}
+}
+
+func TestServerCancelRequest(t *testing.T) {
+	reader1, writer1 := io.Pipe()
+	reader2, writer2 := io.Pipe()
+
+	var (
+		in = struct {
+			reader io.ReadCloser
+			writer io.WriteCloser
+		}{
+			reader: reader1,
+			writer: writer1,
+		}
+
+		out = struct {
+			reader io.ReadCloser
+			writer io.WriteCloser
+		}{
+			reader: reader2,
+			writer: writer2,
+		}
+
+		outScan = bufio.NewScanner(out.reader)
+	)
+
+	server, err := NewServer(transport.NewMockServerTransport(in.reader, out.writer))
+	if err != nil {
+		t.Fatalf("NewServer: %+v", err)
+	}
+
+	testTool, err := protocol.NewTool("test_tool", "test_tool", currentTimeReq{})
+	if err != nil {
+		t.Fatalf("NewTool: %+v", err)
+		return
+	}
+	started := make(chan struct{})
+	canceled := make(chan struct{})
+	server.RegisterTool(testTool, func(ctx context.Context, _ *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+		close(started)
+		<-ctx.Done()
+		close(canceled)
+		return nil, ctx.Err()
+	})
+
+	go func() {
+		if err := server.Run(); err != nil {
+			t.Errorf("server start: %+v", err)
+		}
+	}()
+
+	testServerInit(t, server, in.writer, outScan)
+
+	requestID := uuid.NewString()
+	reqBytes, err := json.Marshal(protocol.NewJSONRPCRequest(requestID, protocol.ToolsCall, protocol.NewCallToolRequest(testTool.Name, map[string]interface{}{"timezone": "UTC"})))
+	if err != nil {
+		t.Fatalf("json Marshal: %+v", err)
+	}
+	if _, err = in.writer.Write(append(reqBytes, "\n"...)); err != nil {
+		t.Fatalf("in Write: %+v", err)
+	}
+	<-started
+
+	notifyBytes, err := json.Marshal(protocol.NewJSONRPCNotification(protocol.NotificationCancelled,
+		protocol.NewCancelledNotification(requestID, "user gave up")))
+	if err != nil {
+		t.Fatalf("json Marshal: %+v", err)
+	}
+	if _, err = in.writer.Write(append(notifyBytes, "\n"...)); err != nil {
+		t.Fatalf("in Write: %+v", err)
+	}
+
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Fatal("handler context was not canceled")
+	}
+}
+
+func TestServerListRoots(t *testing.T) {
+	reader1, writer1 := io.Pipe()
+	reader2, writer2 := io.Pipe()
+
+	var (
+		in = struct {
+			reader io.ReadCloser
+			writer io.WriteCloser
+		}{
+			reader: reader1,
+			writer: writer1,
+		}
+
+		out = struct {
+			reader io.ReadCloser
+			writer io.WriteCloser
+		}{
+			reader: reader2,
+			writer: writer2,
+		}
+
+		outScan = bufio.NewScanner(out.reader)
+	)
+
+	var server *Server
+	rootsCh := make(chan *protocol.ListRootsResult, 1)
+	server, err := NewServer(transport.NewMockServerTransport(in.reader, out.writer),
+		WithRootsListChangedHandler(func(ctx context.Context, _ string) {
+			result, err := server.ListRoots(ctx)
+			if err != nil {
+				t.Errorf("ListRoots: %+v", err)
+				return
+			}
+			rootsCh <- result
+		}))
+	if err != nil {
+		t.Fatalf("NewServer: %+v", err)
+	}
+
+	go func() {
+		if err := server.Run(); err != nil {
+			t.Errorf("server start: %+v", err)
+		}
+	}()
+
+	testServerInitWithRequest(t, server, in.writer, outScan, protocol.InitializeRequest{
+		ProtocolVersion: protocol.Version,
+		Capabilities:    protocol.ClientCapabilities{Roots: &protocol.RootsCapability{ListChanged: true}},
+	})
+
+	notifyBytes, err := json.Marshal(protocol.NewJSONRPCNotification(protocol.NotificationRootsListChanged, protocol.NewRootsListChangedNotification()))
+	if err != nil {
+		t.Fatalf("json Marshal: %+v", err)
+	}
+	if _, err = in.writer.Write(append(notifyBytes, "\n"...)); err != nil {
+		t.Fatalf("in Write: %+v", err)
+	}
+
+	if !outScan.Scan() {
+		t.Fatalf("outScan: %+v", outScan.Err())
+	}
+	req := &protocol.JSONRPCRequest{}
+	if err = pkg.JSONUnmarshal(outScan.Bytes(), req); err != nil {
+		t.Fatal(err)
+	}
+	if req.Method != protocol.RootsList {
+		t.Fatalf("request method not as expected: %s", req.Method)
+	}
+
+	expectedRoots := protocol.NewListRootsResult([]protocol.Root{{Name: "workspace", URI: "file:///workspace"}})
+	respBytes, err := json.Marshal(protocol.NewJSONRPCSuccessResponse(req.ID, expectedRoots))
+	if err != nil {
+		t.Fatalf("json Marshal: %+v", err)
+	}
+	if _, err = in.writer.Write(append(respBytes, "\n"...)); err != nil {
+		t.Fatalf("in Write: %+v", err)
+	}
+
+	if roots := <-rootsCh; !reflect.DeepEqual(roots, expectedRoots) {
+		t.Fatalf("roots not as expected.\ngot  = %+v\nwant = %+v", roots, expectedRoots)
+	}
+}
+
 func testServerInit(t *testing.T, server *Server, in io.Writer, outScan *bufio.Scanner) {
+	testServerInitWithRequest(t, server, in, outScan, protocol.InitializeRequest{ProtocolVersion: protocol.Version})
+}
+
+func testServerInitWithRequest(t *testing.T, server *Server, in io.Writer, outScan *bufio.Scanner, initRequest protocol.InitializeRequest) {
 	uuid, _ := uuid.NewUUID()
-	req := protocol.NewJSONRPCRequest(uuid, protocol.Initialize, protocol.InitializeRequest{ProtocolVersion: protocol.Version})
+	req := protocol.NewJSONRPCRequest(uuid, protocol.Initialize, initRequest)
 	reqBytes, err := json.Marshal(req)
 	if err != nil {
 		t.Fatalf("json Marshal: %+v", err)
@@ -479,11 +777,11 @@ func testServerInit(t *testing.T, server *Server, in io.Writer, outScan *bufio.S
 		t.Fatal(err)
 	}
 
-	expectedResp := protocol.NewJSONRPCSuccessResponse(uuid, protocol.InitializeResult{
-		ProtocolVersion: protocol.Version,
+	expectedResp := protocol.NewJSONRPCSuccessResponse(uuid, protocol.AdaptToVersion(initRequest.ProtocolVersion, &protocol.InitializeResult{
+		ProtocolVersion: initRequest.ProtocolVersion,
 		Capabilities:    *server.capabilities,
 		ServerInfo:      *server.serverInfo,
-	})
+	}))
 	expectedRespBytes, err := json.Marshal(expectedResp)
 	if err != nil {
 		t.Fatalf("json Marshal: %+v", err)
@@ -506,3 +804,1011 @@ func testServerInit(t *testing.T, server *Server, in io.Writer, outScan *bufio.S
 		t.Fatalf("in Write: %+v", err)
 	}
 }
+
+func TestServerListToolsPagination(t *testing.T) {
+	reader, writer := io.Pipe()
+	defer reader.Close()
+
+	server, err := NewServer(transport.NewMockServerTransport(reader, writer), WithPaginationLimit(2))
+	if err != nil {
+		t.Fatalf("NewServer: %+v", err)
+	}
+
+	for _, name := range []string{"tool_e", "tool_a", "tool_d", "tool_b"} {
+		server.RegisterTool(&protocol.Tool{Name: name}, func(context.Context, *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+			return nil, nil
+		})
+	}
+
+	listNames := func(cursor string) ([]string, string) {
+		rawParams, err := json.Marshal(protocol.ListToolsRequest{PaginatedRequest: protocol.PaginatedRequest{Cursor: cursor}})
+		if err != nil {
+			t.Fatalf("json Marshal: %+v", err)
+		}
+		result, err := server.handleRequestWithListTools(context.Background(), rawParams)
+		if err != nil {
+			t.Fatalf("handleRequestWithListTools: %+v", err)
+		}
+		names := make([]string, 0, len(result.Tools))
+		for _, tool := range result.Tools {
+			names = append(names, tool.Name)
+		}
+		return names, result.NextCursor
+	}
+
+	names, cursor := listNames("")
+	if !reflect.DeepEqual(names, []string{"tool_a", "tool_b"}) || cursor == "" {
+		t.Fatalf("first page not as expected: names=%v, cursor=%q", names, cursor)
+	}
+
+	// registering an entry before the cursor must not shift the following pages
+	server.RegisterTool(&protocol.Tool{Name: "tool_0"}, func(context.Context, *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+		return nil, nil
+	})
+
+	names, cursor = listNames(cursor)
+	if !reflect.DeepEqual(names, []string{"tool_d", "tool_e"}) || cursor != "" {
+		t.Fatalf("second page not as expected: names=%v, cursor=%q", names, cursor)
+	}
+
+	rawParams, err := json.Marshal(protocol.ListToolsRequest{PaginatedRequest: protocol.PaginatedRequest{Cursor: "!invalid!"}})
+	if err != nil {
+		t.Fatalf("json Marshal: %+v", err)
+	}
+	if _, err = server.handleRequestWithListTools(context.Background(), rawParams); !errors.Is(err, pkg.ErrInvalidParams) {
+		t.Fatalf("invalid cursor error not as expected: %+v", err)
+	}
+}
+
+func TestServerHideDestructiveTools(t *testing.T) {
+	reader, writer := io.Pipe()
+	defer reader.Close()
+
+	server, err := NewServer(transport.NewMockServerTransport(reader, writer),
+		WithToolFilter(HideDestructiveTools(func(sessionID string) bool { return sessionID == "admin" })))
+	if err != nil {
+		t.Fatalf("NewServer: %+v", err)
+	}
+
+	handler := func(context.Context, *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+		return protocol.NewCallToolResult([]protocol.Content{&protocol.TextContent{Type: "text", Text: "success"}}, false), nil
+	}
+	server.RegisterTool(protocol.NewToolWithRawSchema("read_file", "", json.RawMessage(`{"type":"object"}`), protocol.WithReadOnlyHint(true)), handler)
+	server.RegisterTool(protocol.NewToolWithRawSchema("write_file", "", json.RawMessage(`{"type":"object"}`), protocol.WithDestructiveHint(false)), handler)
+	// a tool without annotations is destructive by default
+	server.RegisterTool(protocol.NewToolWithRawSchema("delete_file", "", json.RawMessage(`{"type":"object"}`)), handler)
+
+	tests := []struct {
+		sessionID     string
+		expectedTools []string
+	}{
+		{sessionID: "guest", expectedTools: []string{"read_file", "write_file"}},
+		{sessionID: "admin", expectedTools: []string{"delete_file", "read_file", "write_file"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sessionID, func(t *testing.T) {
+			ctx := setSessionIDToCtx(context.Background(), tt.sessionID)
+
+			result, err := server.handleRequestWithListTools(ctx, nil)
+			if err != nil {
+				t.Fatalf("handleRequestWithListTools: %+v", err)
+			}
+			names := make([]string, 0, len(result.Tools))
+			for _, tool := range result.Tools {
+				names = append(names, tool.Name)
+			}
+			if !reflect.DeepEqual(names, tt.expectedTools) {
+				t.Fatalf("tools not as expected.\ngot  = %v\nwant = %v", names, tt.expectedTools)
+			}
+
+			rawParams, err := json.Marshal(protocol.NewCallToolRequest("delete_file", nil))
+			if err != nil {
+				t.Fatalf("json Marshal: %+v", err)
+			}
+			_, err = server.handleRequestWithCallTool(ctx, rawParams)
+			if allowed := tt.sessionID == "admin"; allowed != (err == nil) {
+				t.Fatalf("call hidden tool error not as expected: %+v", err)
+			}
+		})
+	}
+}
+
+func TestServerCallToolStructuredContent(t *testing.T) {
+	reader, writer := io.Pipe()
+	defer reader.Close()
+
+	server, err := NewServer(transport.NewMockServerTransport(reader, writer))
+	if err != nil {
+		t.Fatalf("NewServer: %+v", err)
+	}
+
+	tool := protocol.NewToolWithRawSchema("sum", "", json.RawMessage(`{"type":"object"}`),
+		protocol.WithRawOutputSchema(json.RawMessage(`{"type":"object","properties":{"sum":{"type":"number"}},"required":["sum"]}`)))
+
+	tests := []struct {
+		name              string
+		structuredContent interface{}
+		expectedErr       bool
+	}{
+		{name: "valid", structuredContent: map[string]interface{}{"sum": 3}},
+		{name: "invalid", structuredContent: map[string]interface{}{"sum": "3"}, expectedErr: true},
+		{name: "missing", structuredContent: nil, expectedErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server.RegisterTool(tool, func(context.Context, *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+				if tt.structuredContent == nil {
+					return protocol.NewCallToolResult([]protocol.Content{&protocol.TextContent{Type: "text", Text: "3"}}, false), nil
+				}
+				return protocol.NewCallToolResultWithStructuredContent(tt.structuredContent, false)
+			})
+
+			rawParams, err := json.Marshal(protocol.NewCallToolRequest("sum", nil))
+			if err != nil {
+				t.Fatalf("json Marshal: %+v", err)
+			}
+			if _, err = server.handleRequestWithCallTool(context.Background(), rawParams); (err != nil) != tt.expectedErr {
+				t.Fatalf("handleRequestWithCallTool error not as expected: %+v", err)
+			}
+		})
+	}
+}
+
+func TestServerElicit(t *testing.T) {
+	reader1, writer1 := io.Pipe()
+	reader2, writer2 := io.Pipe()
+
+	var (
+		in = struct {
+			reader io.ReadCloser
+			writer io.WriteCloser
+		}{
+			reader: reader1,
+			writer: writer1,
+		}
+
+		out = struct {
+			reader io.ReadCloser
+			writer io.WriteCloser
+		}{
+			reader: reader2,
+			writer: writer2,
+		}
+
+		outScan = bufio.NewScanner(out.reader)
+	)
+
+	type confirmReq struct {
+		Confirm bool `json:"confirm"`
+	}
+
+	server, err := NewServer(transport.NewMockServerTransport(in.reader, out.writer))
+	if err != nil {
+		t.Fatalf("NewServer: %+v", err)
+	}
+	server.RegisterTool(protocol.NewToolWithRawSchema("deploy", "", json.RawMessage(`{"type":"object"}`)),
+		func(ctx context.Context, _ *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+			result, err := server.Elicit(ctx, "confirm the deployment", confirmReq{})
+			if err != nil {
+				return nil, err
+			}
+			return protocol.NewCallToolResult([]protocol.Content{&protocol.TextContent{Type: "text", Text: string(result.Action)}}, false), nil
+		})
+
+	go func() {
+		if err := server.Run(); err != nil {
+			t.Errorf("server start: %+v", err)
+		}
+	}()
+
+	testServerInitWithRequest(t, server, in.writer, outScan, protocol.InitializeRequest{
+		ProtocolVersion: protocol.Version,
+		Capabilities:    protocol.ClientCapabilities{Elicitation: struct{}{}},
+	})
+
+	reqBytes, err := json.Marshal(protocol.NewJSONRPCRequest("call", protocol.ToolsCall, protocol.NewCallToolRequest("deploy", nil)))
+	if err != nil {
+		t.Fatalf("json Marshal: %+v", err)
+	}
+	if _, err = in.writer.Write(append(reqBytes, "\n"...)); err != nil {
+		t.Fatalf("in Write: %+v", err)
+	}
+
+	if !outScan.Scan() {
+		t.Fatalf("outScan: %+v", outScan.Err())
+	}
+	req := &protocol.JSONRPCRequest{}
+	if err = pkg.JSONUnmarshal(outScan.Bytes(), req); err != nil {
+		t.Fatal(err)
+	}
+	if req.Method != protocol.ElicitationCreate {
+		t.Fatalf("request method not as expected: %s", req.Method)
+	}
+	elicitReq := &protocol.ElicitRequest{}
+	if err = pkg.JSONUnmarshal(req.RawParams, elicitReq); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := elicitReq.RequestedSchema.Properties["confirm"]; !ok {
+		t.Fatalf("requested schema not as expected: %+v", elicitReq.RequestedSchema)
+	}
+
+	respBytes, err := json.Marshal(protocol.NewJSONRPCSuccessResponse(req.ID,
+		protocol.NewElicitResult(protocol.ElicitAccept, map[string]interface{}{"confirm": true})))
+	if err != nil {
+		t.Fatalf("json Marshal: %+v", err)
+	}
+	if _, err = in.writer.Write(append(respBytes, "\n"...)); err != nil {
+		t.Fatalf("in Write: %+v", err)
+	}
+
+	if !outScan.Scan() {
+		t.Fatalf("outScan: %+v", outScan.Err())
+	}
+	resp := &protocol.JSONRPCResponse{}
+	if err = pkg.JSONUnmarshal(outScan.Bytes(), resp); err != nil {
+		t.Fatal(err)
+	}
+	result := &protocol.CallToolResult{}
+	if err = pkg.JSONUnmarshal(resp.RawResult, result); err != nil {
+		t.Fatal(err)
+	}
+	if text, ok := result.Content[0].(*protocol.TextContent); !ok || text.Text != string(protocol.ElicitAccept) {
+		t.Fatalf("call tool result not as expected: %+v", result.Content)
+	}
+}
+
+func TestServerNegotiateVersion(t *testing.T) {
+	reader1, writer1 := io.Pipe()
+	reader2, writer2 := io.Pipe()
+
+	var (
+		in = struct {
+			reader io.ReadCloser
+			writer io.WriteCloser
+		}{
+			reader: reader1,
+			writer: writer1,
+		}
+
+		out = struct {
+			reader io.ReadCloser
+			writer io.WriteCloser
+		}{
+			reader: reader2,
+			writer: writer2,
+		}
+
+		outScan = bufio.NewScanner(out.reader)
+	)
+
+	server, err := NewServer(transport.NewMockServerTransport(in.reader, out.writer))
+	if err != nil {
+		t.Fatalf("NewServer: %+v", err)
+	}
+	server.RegisterTool(protocol.NewToolWithRawSchema("read_file", "", json.RawMessage(`{"type":"object"}`),
+		protocol.WithToolTitle("Read File"), protocol.WithReadOnlyHint(true)),
+		func(context.Context, *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+			return nil, nil
+		})
+
+	go func() {
+		if err := server.Run(); err != nil {
+			t.Errorf("server start: %+v", err)
+		}
+	}()
+
+	call := func(method protocol.Method, params interface{}) []byte {
+		reqBytes, err := json.Marshal(protocol.NewJSONRPCRequest(uuid.NewString(), method, params))
+		if err != nil {
+			t.Fatalf("json Marshal: %+v", err)
+		}
+		if _, err = in.writer.Write(append(reqBytes, "\n"...)); err != nil {
+			t.Fatalf("in Write: %+v", err)
+		}
+		if !outScan.Scan() {
+			t.Fatalf("outScan: %+v", outScan.Err())
+		}
+		resp := &protocol.JSONRPCResponse{}
+		if err = pkg.JSONUnmarshal(outScan.Bytes(), resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp.RawResult
+	}
+
+	initResult := make(map[string]interface{})
+	if err = pkg.JSONUnmarshal(call(protocol.Initialize, protocol.InitializeRequest{ProtocolVersion: protocol.Version20241105}), &initResult); err != nil {
+		t.Fatal(err)
+	}
+	if initResult["protocolVersion"] != protocol.Version20241105 {
+		t.Fatalf("protocolVersion not as expected: %v", initResult["protocolVersion"])
+	}
+	if _, ok := initResult["capabilities"].(map[string]interface{})["completions"]; ok {
+		t.Fatalf("completions capability should be omitted for %s", protocol.Version20241105)
+	}
+
+	notifyBytes, err := json.Marshal(protocol.NewJSONRPCNotification(protocol.NotificationInitialized, nil))
+	if err != nil {
+		t.Fatalf("json Marshal: %+v", err)
+	}
+	if _, err = in.writer.Write(append(notifyBytes, "\n"...)); err != nil {
+		t.Fatalf("in Write: %+v", err)
+	}
+
+	listResult := make(map[string]interface{})
+	if err = pkg.JSONUnmarshal(call(protocol.ToolsList, protocol.NewListToolsRequest()), &listResult); err != nil {
+		t.Fatal(err)
+	}
+	expectedTools := []interface{}{map[string]interface{}{"name": "read_file", "inputSchema": map[string]interface{}{"type": "object"}}}
+	if !reflect.DeepEqual(listResult["tools"], expectedTools) {
+		t.Fatalf("tools not as expected.\ngot  = %v\nwant = %v", listResult["tools"], expectedTools)
+	}
+}
+
+func TestServerBatch(t *testing.T) {
+	reader1, writer1 := io.Pipe()
+	reader2, writer2 := io.Pipe()
+
+	var (
+		in = struct {
+			reader io.ReadCloser
+			writer io.WriteCloser
+		}{
+			reader: reader1,
+			writer: writer1,
+		}
+
+		out = struct {
+			reader io.ReadCloser
+			writer io.WriteCloser
+		}{
+			reader: reader2,
+			writer: writer2,
+		}
+
+		outScan = bufio.NewScanner(out.reader)
+	)
+
+	server, err := NewServer(transport.NewMockServerTransport(in.reader, out.writer))
+	if err != nil {
+		t.Fatalf("NewServer: %+v", err)
+	}
+	server.RegisterTool(protocol.NewToolWithRawSchema("slow_tool", "", json.RawMessage(`{"type":"object"}`)),
+		func(context.Context, *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+			time.Sleep(50 * time.Millisecond)
+			return protocol.NewCallToolResult([]protocol.Content{&protocol.TextContent{Type: "text", Text: "slow"}}, false), nil
+		})
+
+	go func() {
+		if err := server.Run(); err != nil {
+			t.Errorf("server start: %+v", err)
+		}
+	}()
+
+	// batching was removed in 2025-06-18
+	testServerInitWithRequest(t, server, in.writer, outScan, protocol.InitializeRequest{ProtocolVersion: protocol.Version20250326})
+
+	batch := []interface{}{
+		protocol.NewJSONRPCRequest("1", protocol.ToolsCall, protocol.NewCallToolRequest("slow_tool", nil)),
+		protocol.NewJSONRPCNotification(protocol.NotificationRootsListChanged, nil),
+		protocol.NewJSONRPCRequest("2", protocol.Ping, protocol.NewPingRequest()),
+		protocol.NewJSONRPCRequest("3", "unknown/method", nil),
+	}
+	batchBytes, err := json.Marshal(batch)
+	if err != nil {
+		t.Fatalf("json Marshal: %+v", err)
+	}
+	if _, err = in.writer.Write(append(batchBytes, "\n"...)); err != nil {
+		t.Fatalf("in Write: %+v", err)
+	}
+
+	if !outScan.Scan() {
+		t.Fatalf("outScan: %+v", outScan.Err())
+	}
+	var responses []*protocol.JSONRPCResponse
+	if err = pkg.JSONUnmarshal(outScan.Bytes(), &responses); err != nil {
+		t.Fatal(err)
+	}
+	if len(responses) != 3 {
+		t.Fatalf("responses length not as expected: %d", len(responses))
+	}
+	for i, id := range []string{"1", "2", "3"} {
+		if responses[i].ID != id {
+			t.Fatalf("responses[%d].ID = %v, want %s", i, responses[i].ID, id)
+		}
+	}
+	if responses[0].Error != nil || responses[1].Error != nil {
+		t.Fatalf("unexpected error response: %+v, %+v", responses[0].Error, responses[1].Error)
+	}
+	if responses[2].Error == nil || responses[2].Error.Code != protocol.MethodNotFound {
+		t.Fatalf("error response not as expected: %+v", responses[2].Error)
+	}
+}
+
+func TestServerBatchRejectedByLatestVersion(t *testing.T) {
+	inReader, inWriter := io.Pipe()
+	outReader, outWriter := io.Pipe()
+	outScan := bufio.NewScanner(outReader)
+
+	server, err := NewServer(transport.NewMockServerTransport(inReader, outWriter))
+	if err != nil {
+		t.Fatalf("NewServer: %+v", err)
+	}
+	go func() {
+		if err := server.Run(); err != nil {
+			t.Errorf("server start: %+v", err)
+		}
+	}()
+
+	testServerInit(t, server, inWriter, outScan)
+
+	batchBytes, err := json.Marshal([]interface{}{protocol.NewJSONRPCRequest("1", protocol.Ping, protocol.NewPingRequest())})
+	if err != nil {
+		t.Fatalf("json Marshal: %+v", err)
+	}
+	if _, err = inWriter.Write(append(batchBytes, "\n"...)); err != nil {
+		t.Fatalf("in Write: %+v", err)
+	}
+
+	if !outScan.Scan() {
+		t.Fatalf("outScan: %+v", outScan.Err())
+	}
+	var response *protocol.JSONRPCResponse
+	if err = pkg.JSONUnmarshal(outScan.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Error == nil || response.Error.Code != protocol.InvalidRequest {
+		t.Fatalf("expected invalid request error, got %s", outScan.Bytes())
+	}
+}
+
+func TestServerMetaInCtx(t *testing.T) {
+	reader, writer := io.Pipe()
+	defer reader.Close()
+
+	server, err := NewServer(transport.NewMockServerTransport(reader, writer))
+	if err != nil {
+		t.Fatalf("NewServer: %+v", err)
+	}
+
+	metaCh := make(chan map[string]interface{}, 1)
+	server.RegisterTool(protocol.NewToolWithRawSchema("test_tool", "", json.RawMessage(`{"type":"object"}`)),
+		func(ctx context.Context, _ *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+			meta, _ := GetMetaFromCtx(ctx)
+			metaCh <- meta
+			return protocol.NewCallToolResult(nil, false), nil
+		})
+
+	request := protocol.NewCallToolRequest("test_tool", nil)
+	request.Meta = map[string]interface{}{"tenant": "t1", "trace": map[string]interface{}{"id": "abc"}}
+	rawParams, err := json.Marshal(request)
+	if err != nil {
+		t.Fatalf("json Marshal: %+v", err)
+	}
+
+	resp := server.receiveRequest(context.Background(), "", &protocol.JSONRPCRequest{ID: "1", Method: protocol.ToolsCall, RawParams: rawParams})
+	if resp.Error != nil {
+		t.Fatalf("receiveRequest: %+v", resp.Error)
+	}
+	if meta := <-metaCh; !reflect.DeepEqual(meta, request.Meta) {
+		t.Fatalf("meta not as expected.\ngot  = %v\nwant = %v", meta, request.Meta)
+	}
+}
+
+func TestServerMiddleware(t *testing.T) {
+	reader, writer := io.Pipe()
+	defer reader.Close()
+
+	var calls []string
+	record := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(ctx context.Context, request *Request) (protocol.ServerResponse, error) {
+				calls = append(calls, fmt.Sprintf("%s:%s:%s", name, request.Method, request.SessionID))
+				return next(ctx, request)
+			}
+		}
+	}
+	deny := func(next Handler) Handler {
+		return func(ctx context.Context, request *Request) (protocol.ServerResponse, error) {
+			if request.Method == protocol.PromptsList {
+				return nil, fmt.Errorf("%w: denied", pkg.ErrInvalidParams)
+			}
+			return next(ctx, request)
+		}
+	}
+	toolMiddleware := func(tag string) ToolMiddleware {
+		return func(next ToolHandlerFunc) ToolHandlerFunc {
+			return func(ctx context.Context, request *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+				result, err := next(ctx, request)
+				if err != nil {
+					return nil, err
+				}
+				result.Content = append(result.Content, &protocol.TextContent{Type: "text", Text: tag})
+				return result, nil
+			}
+		}
+	}
+
+	server, err := NewServer(transport.NewMockServerTransport(reader, writer),
+		WithMiddleware(record("outer"), record("inner")), WithMiddleware(deny),
+		WithToolMiddleware(toolMiddleware("outer"), toolMiddleware("inner")))
+	if err != nil {
+		t.Fatalf("NewServer: %+v", err)
+	}
+
+	server.RegisterTool(protocol.NewToolWithRawSchema("test_tool", "", json.RawMessage(`{"type":"object"}`)),
+		func(_ context.Context, _ *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+			return protocol.NewCallToolResult([]protocol.Content{&protocol.TextContent{Type: "text", Text: "handler"}}, false), nil
+		})
+
+	rawParams, err := json.Marshal(protocol.NewCallToolRequest("test_tool", nil))
+	if err != nil {
+		t.Fatalf("json Marshal: %+v", err)
+	}
+	resp := server.receiveRequest(context.Background(), "", &protocol.JSONRPCRequest{ID: "1", Method: protocol.ToolsCall, RawParams: rawParams})
+	if resp.Error != nil {
+		t.Fatalf("receiveRequest: %+v", resp.Error)
+	}
+
+	result, ok := resp.Result.(*protocol.CallToolResult)
+	if !ok {
+		t.Fatalf("unexpected result type %T", resp.Result)
+	}
+	var texts []string
+	for _, content := range result.Content {
+		texts = append(texts, content.(*protocol.TextContent).Text)
+	}
+	if expected := []string{"handler", "inner", "outer"}; !reflect.DeepEqual(texts, expected) {
+		t.Fatalf("tool middleware order not as expected.\ngot  = %v\nwant = %v", texts, expected)
+	}
+	if expected := []string{"outer:tools/call:", "inner:tools/call:"}; !reflect.DeepEqual(calls, expected) {
+		t.Fatalf("middleware order not as expected.\ngot  = %v\nwant = %v", calls, expected)
+	}
+
+	resp = server.receiveRequest(context.Background(), "", &protocol.JSONRPCRequest{ID: "2", Method: protocol.PromptsList, RawParams: json.RawMessage(`{}`)})
+	if resp.Error == nil || resp.Error.Code != protocol.InvalidParams {
+		t.Fatalf("expected invalid params error, got %+v", resp.Error)
+	}
+}
+
+func TestRegisterTypedTool(t *testing.T) {
+	reader, writer := io.Pipe()
+	defer reader.Close()
+
+	server, err := NewServer(transport.NewMockServerTransport(reader, writer))
+	if err != nil {
+		t.Fatalf("NewServer: %+v", err)
+	}
+
+	type weatherReq struct {
+		City string `json:"city" description:"city name"`
+	}
+	type weatherResp struct {
+		City        string  `json:"city"`
+		Temperature float64 `json:"temperature"`
+	}
+	if err = RegisterTypedTool(server, "weather", "", func(_ context.Context, req weatherReq) (*weatherResp, error) {
+		if req.City == "Atlantis" {
+			return nil, nil
+		}
+		return &weatherResp{City: req.City, Temperature: 21.5}, nil
+	}); err != nil {
+		t.Fatalf("RegisterTypedTool: %+v", err)
+	}
+	if err = RegisterTypedTool(server, "greet", "", func(_ context.Context, req weatherReq) (string, error) {
+		return "hello " + req.City, nil
+	}); err != nil {
+		t.Fatalf("RegisterTypedTool: %+v", err)
+	}
+
+	rawResult := protocol.NewCallToolResult([]protocol.Content{&protocol.TextContent{Type: "text", Text: "raw"}}, false)
+	if err = RegisterTypedTool(server, "raw", "", func(_ context.Context, _ weatherReq) (protocol.CallToolResult, error) {
+		return *rawResult, nil
+	}); err != nil {
+		t.Fatalf("RegisterTypedTool: %+v", err)
+	}
+
+	entry, ok := server.tools.Load("weather")
+	if !ok || !entry.tool.HasOutputSchema() || entry.outputSchema == nil {
+		t.Fatalf("weather tool should declare a compiled output schema")
+	}
+	if entry, _ = server.tools.Load("greet"); entry.tool.HasOutputSchema() {
+		t.Fatalf("greet tool should not declare an output schema")
+	}
+
+	call := func(name string, arguments map[string]interface{}) *protocol.JSONRPCResponse {
+		rawParams, err := json.Marshal(protocol.NewCallToolRequest(name, arguments))
+		if err != nil {
+			t.Fatalf("json Marshal: %+v", err)
+		}
+		return server.receiveRequest(context.Background(), "", &protocol.JSONRPCRequest{ID: "1", Method: protocol.ToolsCall, RawParams: rawParams})
+	}
+
+	resp := call("weather", map[string]interface{}{"city": "Paris"})
+	if resp.Error != nil {
+		t.Fatalf("call weather: %+v", resp.Error)
+	}
+	result := resp.Result.(*protocol.CallToolResult)
+	if expected := (&weatherResp{City: "Paris", Temperature: 21.5}); !reflect.DeepEqual(result.StructuredContent, expected) {
+		t.Fatalf("structured content not as expected.\ngot  = %+v\nwant = %+v", result.StructuredContent, expected)
+	}
+
+	resp = call("greet", map[string]interface{}{"city": "Paris"})
+	if resp.Error != nil {
+		t.Fatalf("call greet: %+v", resp.Error)
+	}
+	expected := protocol.NewCallToolResult([]protocol.Content{&protocol.TextContent{Type: "text", Text: "hello Paris"}}, false)
+	if !reflect.DeepEqual(resp.Result, expected) {
+		t.Fatalf("result not as expected.\ngot  = %+v\nwant = %+v", resp.Result, expected)
+	}
+
+	resp = call("weather", map[string]interface{}{"city": "Atlantis"})
+	if resp.Error != nil {
+		t.Fatalf("call weather: %+v", resp.Error)
+	}
+	if result = resp.Result.(*protocol.CallToolResult); !result.IsError || result.StructuredContent != nil {
+		t.Fatalf("a nil result should be an error result: %+v", result)
+	}
+
+	if resp = call("raw", map[string]interface{}{"city": "Paris"}); resp.Error != nil || !reflect.DeepEqual(resp.Result, rawResult) {
+		t.Fatalf("result not as expected.\ngot  = %+v\nwant = %+v", resp.Result, rawResult)
+	}
+	if entry, _ = server.tools.Load("raw"); entry.tool.HasOutputSchema() {
+		t.Fatalf("raw tool should not declare an output schema")
+	}
+
+	for _, arguments := range []map[string]interface{}{nil, {"city": 1}} {
+		if resp = call("greet", arguments); resp.Error == nil || resp.Error.Code != protocol.InvalidParams {
+			t.Fatalf("arguments %v: expected invalid params error, got %+v", arguments, resp.Error)
+		}
+	}
+}
+
+func TestServerValidateToolArguments(t *testing.T) {
+	reader, writer := io.Pipe()
+	defer reader.Close()
+
+	server, err := NewServer(transport.NewMockServerTransport(reader, writer))
+	if err != nil {
+		t.Fatalf("NewServer: %+v", err)
+	}
+
+	handler := func(_ context.Context, _ *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+		return protocol.NewCallToolResult(nil, false), nil
+	}
+	server.RegisterTool(protocol.NewToolWithRawSchema("search", "", json.RawMessage(`{
+		"type": "object",
+		"properties": {"query": {"type": "string"}, "limit": {"type": "integer"}},
+		"required": ["query"]
+	}`)), handler)
+	broken := protocol.NewToolWithRawSchema("broken", "", json.RawMessage(`{"type": "object", "properties": {"a": {"type": "text"}}}`))
+	if err = server.TryRegisterTool(broken, handler); err == nil || !strings.Contains(err.Error(), "invalid input schema") {
+		t.Fatalf("TryRegisterTool should reject an invalid input schema, got %v", err)
+	}
+	server.RegisterTool(broken, handler)
+	server.RegisterTool(protocol.NewToolWithRawSchema("move", "", json.RawMessage(`{
+		"type": "object",
+		"properties": {"point": {"type": "array", "items": [{"type": "number"}, {"type": "number"}]}}
+	}`)), handler)
+	if entry, ok := server.tools.Load("move"); !ok || entry.inputSchema != nil {
+		t.Fatalf("a tool with tuple items should be registered without a compiled input schema")
+	}
+	brokenOutput := protocol.NewToolWithRawSchema("broken_output", "", json.RawMessage(`{"type": "object"}`),
+		protocol.WithRawOutputSchema(json.RawMessage(`{"type": "object", "required": "sum"}`)))
+	if err = server.TryRegisterTool(brokenOutput, handler); err == nil || !strings.Contains(err.Error(), "invalid output schema") {
+		t.Fatalf("TryRegisterTool should reject an invalid output schema, got %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		tool      string
+		arguments string
+		wantCode  int
+		wantError string
+	}{
+		{name: "valid", tool: "search", arguments: `{"query": "go", "limit": 10}`},
+		{name: "missing_required", tool: "search", arguments: `{}`, wantCode: protocol.InvalidParams, wantError: `/query: required property is missing`},
+		{name: "wrong_type", tool: "search", arguments: `{"query": "go", "limit": "10"}`, wantCode: protocol.InvalidParams, wantError: "/limit: expected integer, got string"},
+		{name: "invalid_schema_not_validated", tool: "broken", arguments: `{"a": 1}`},
+		{name: "tuple_items_not_validated", tool: "move", arguments: `{"point": "origin"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rawParams, err := json.Marshal(protocol.NewCallToolRequestWithRawArguments(tt.tool, json.RawMessage(tt.arguments)))
+			if err != nil {
+				t.Fatalf("json Marshal: %+v", err)
+			}
+			resp := server.receiveRequest(context.Background(), "", &protocol.JSONRPCRequest{ID: "1", Method: protocol.ToolsCall, RawParams: rawParams})
+			if tt.wantCode == 0 {
+				if resp.Error != nil {
+					t.Fatalf("receiveRequest: %+v", resp.Error)
+				}
+				return
+			}
+			if resp.Error == nil || resp.Error.Code != tt.wantCode || !strings.Contains(resp.Error.Message, tt.wantError) {
+				t.Fatalf("error not as expected.\ngot  = %+v\nwant = code %d containing %q", resp.Error, tt.wantCode, tt.wantError)
+			}
+		})
+	}
+
+	resp := server.receiveRequest(context.Background(), "", &protocol.JSONRPCRequest{ID: "2", Method: protocol.ToolsList, RawParams: json.RawMessage(`{}`)})
+	if resp.Error != nil {
+		t.Fatalf("receiveRequest: %+v", resp.Error)
+	}
+	var names []string
+	for _, tool := range resp.Result.(*protocol.ListToolsResult).Tools {
+		names = append(names, tool.Name)
+	}
+	sort.Strings(names)
+	if expected := []string{"broken", "move", "search"}; !reflect.DeepEqual(names, expected) {
+		t.Fatalf("listed tools not as expected.\ngot  = %v\nwant = %v", names, expected)
+	}
+}
+
+func TestRegisterTypedPrompt(t *testing.T) {
+	reader, writer := io.Pipe()
+	defer reader.Close()
+
+	server, err := NewServer(transport.NewMockServerTransport(reader, writer))
+	if err != nil {
+		t.Fatalf("NewServer: %+v", err)
+	}
+
+	type reviewArgs struct {
+		Code     string `json:"code" description:"the code to review"`
+		MaxItems int    `json:"max_items,omitempty"`
+	}
+	if err = RegisterTypedPrompt(server, "review", "review code", func(_ context.Context, args reviewArgs) (*protocol.GetPromptResult, error) {
+		text := fmt.Sprintf("review %s, at most %d items", args.Code, args.MaxItems)
+		return protocol.NewGetPromptResult([]protocol.PromptMessage{
+			{Role: protocol.RoleUser, Content: &protocol.TextContent{Type: "text", Text: text}},
+		}, ""), nil
+	}); err != nil {
+		t.Fatalf("RegisterTypedPrompt: %+v", err)
+	}
+
+	entry, ok := server.prompts.Load("review")
+	if !ok {
+		t.Fatalf("prompt not registered")
+	}
+	expectedArguments := []protocol.PromptArgument{{Name: "code", Description: "the code to review", Required: true}, {Name: "max_items"}}
+	if !reflect.DeepEqual(entry.prompt.Arguments, expectedArguments) {
+		t.Fatalf("arguments not as expected.\ngot  = %+v\nwant = %+v", entry.prompt.Arguments, expectedArguments)
+	}
+
+	get := func(arguments map[string]string) *protocol.JSONRPCResponse {
+		rawParams, err := json.Marshal(protocol.NewGetPromptRequest("review", arguments))
+		if err != nil {
+			t.Fatalf("json Marshal: %+v", err)
+		}
+		return server.receiveRequest(context.Background(), "", &protocol.JSONRPCRequest{ID: "1", Method: protocol.PromptsGet, RawParams: rawParams})
+	}
+
+	resp := get(map[string]string{"code": "main.go", "max_items": "5"})
+	if resp.Error != nil {
+		t.Fatalf("get prompt: %+v", resp.Error)
+	}
+	text := resp.Result.(*protocol.GetPromptResult).Messages[0].Content.(*protocol.TextContent).Text
+	if expected := "review main.go, at most 5 items"; text != expected {
+		t.Fatalf("text not as expected.\ngot  = %s\nwant = %s", text, expected)
+	}
+
+	for _, arguments := range []map[string]string{{}, {"code": "main.go", "max_items": "many"}} {
+		if resp = get(arguments); resp.Error == nil || resp.Error.Code != protocol.InvalidParams {
+			t.Fatalf("arguments %v: expected invalid params error, got %+v", arguments, resp.Error)
+		}
+	}
+}
+
+func TestServerCustomMethod(t *testing.T) {
+	reader, writer := io.Pipe()
+	defer reader.Close()
+
+	var methods []protocol.Method
+	record := func(next Handler) Handler {
+		return func(ctx context.Context, request *Request) (protocol.ServerResponse, error) {
+			methods = append(methods, request.Method)
+			return next(ctx, request)
+		}
+	}
+
+	server, err := NewServer(transport.NewMockServerTransport(reader, writer),
+		WithExperimentalCapability("acme/search", map[string]interface{}{"version": "1"}),
+		WithCapabilities(protocol.ServerCapabilities{Experimental: map[string]interface{}{"acme/index": true}}),
+		WithMiddleware(record))
+	if err != nil {
+		t.Fatalf("NewServer: %+v", err)
+	}
+	expectedExperimental := map[string]interface{}{"acme/index": true, "acme/search": map[string]interface{}{"version": "1"}}
+	if !reflect.DeepEqual(server.capabilities.Experimental, expectedExperimental) {
+		t.Fatalf("experimental capabilities not as expected.\ngot  = %+v\nwant = %+v", server.capabilities.Experimental, expectedExperimental)
+	}
+
+	type searchParams struct {
+		Query string `json:"query"`
+	}
+	server.HandleMethod("acme/search", func(_ context.Context, rawParams json.RawMessage) (interface{}, error) {
+		var params searchParams
+		if err := pkg.JSONUnmarshal(rawParams, &params); err != nil {
+			return nil, err
+		}
+		if params.Query == "" {
+			return nil, fmt.Errorf("%w: query is required", pkg.ErrInvalidParams)
+		}
+		return []string{params.Query + ".go"}, nil
+	})
+	var notified json.RawMessage
+	server.HandleNotification("acme/reindex", func(_ context.Context, rawParams json.RawMessage) error {
+		notified = rawParams
+		return nil
+	})
+	server.HandleMethod(string(protocol.SamplingCreateMessage), func(context.Context, json.RawMessage) (interface{}, error) { return nil, nil })
+	server.HandleNotification(string(protocol.NotificationLogMessage), func(context.Context, json.RawMessage) error { return nil })
+	if _, ok := server.methodHandlers.Load(string(protocol.SamplingCreateMessage)); ok {
+		t.Fatalf("method defined by MCP should not be registered")
+	}
+	if _, ok := server.notificationHandlers.Load(string(protocol.NotificationLogMessage)); ok {
+		t.Fatalf("notification defined by MCP should not be registered")
+	}
+
+	resp := server.receiveRequest(context.Background(), "", &protocol.JSONRPCRequest{ID: "1", Method: "acme/search", RawParams: json.RawMessage(`{"query":"main"}`)})
+	if resp.Error != nil {
+		t.Fatalf("receiveRequest: %+v", resp.Error)
+	}
+	if !reflect.DeepEqual(resp.Result, []string{"main.go"}) {
+		t.Fatalf("result not as expected: %+v", resp.Result)
+	}
+	if expected := []protocol.Method{"acme/search"}; !reflect.DeepEqual(methods, expected) {
+		t.Fatalf("middleware methods not as expected.\ngot  = %v\nwant = %v", methods, expected)
+	}
+
+	tests := []struct {
+		method   protocol.Method
+		params   string
+		expected int
+	}{
+		{method: "acme/search", params: `{}`, expected: protocol.InvalidParams},
+		{method: "acme/unknown", params: `{}`, expected: protocol.MethodNotFound},
+	}
+	for _, tt := range tests {
+		resp = server.receiveRequest(context.Background(), "", &protocol.JSONRPCRequest{ID: "2", Method: tt.method, RawParams: json.RawMessage(tt.params)})
+		if resp.Error == nil || resp.Error.Code != tt.expected {
+			t.Fatalf("%s: expected error code %d, got %+v", tt.method, tt.expected, resp.Error)
+		}
+	}
+
+	if err = server.receiveNotify("", &protocol.JSONRPCNotification{Method: "acme/reindex", RawParams: json.RawMessage(`{"path":"/"}`)}); err != nil {
+		t.Fatalf("receiveNotify: %+v", err)
+	}
+	if string(notified) != `{"path":"/"}` {
+		t.Fatalf("notification params not as expected: %s", notified)
+	}
+	if err = server.receiveNotify("", &protocol.JSONRPCNotification{Method: "acme/unknown"}); !errors.Is(err, pkg.ErrMethodNotSupport) {
+		t.Fatalf("expected method not support error, got %+v", err)
+	}
+}
+
+func TestServerInitializeOldVersionOverStreamableHTTP(t *testing.T) {
+	svr, handler, err := transport.NewStreamableHTTPServerTransportAndHandler(
+		transport.WithStreamableHTTPServerTransportAndHandlerOptionStateMode(transport.Stateful))
+	if err != nil {
+		t.Fatalf("NewStreamableHTTPServerTransportAndHandler: %+v", err)
+	}
+	if _, err = NewServer(svr); err != nil {
+		t.Fatalf("NewServer: %+v", err)
+	}
+
+	body, err := json.Marshal(protocol.NewJSONRPCRequest(1, protocol.Initialize, protocol.InitializeRequest{ProtocolVersion: protocol.Version20241105}))
+	if err != nil {
+		t.Fatalf("json Marshal: %+v", err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/mcp", bytes.NewReader(body))
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	w := httptest.NewRecorder()
+	handler.HandleMCP().ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status not as expected: got %d, body=%s", w.Code, w.Body.String())
+	}
+	if w.Header().Get("Mcp-Session-Id") == "" {
+		t.Fatalf("session id header is missing")
+	}
+
+	var resp struct {
+		Result protocol.InitializeResult `json:"result"`
+	}
+	if err = json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("json Unmarshal: %+v", err)
+	}
+	if resp.Result.ProtocolVersion != protocol.Version20241105 {
+		t.Fatalf("protocol version not as expected: %s", resp.Result.ProtocolVersion)
+	}
+	if resp.Result.Capabilities.Completions != nil {
+		t.Fatalf("completions capability should not be sent to a %s client: %s", protocol.Version20241105, w.Body.String())
+	}
+}
+
+func TestServerCancelRequestOverStreamableHTTP(t *testing.T) {
+	svr, handler, err := transport.NewStreamableHTTPServerTransportAndHandler(
+		transport.WithStreamableHTTPServerTransportAndHandlerOptionStateMode(transport.Stateful))
+	if err != nil {
+		t.Fatalf("NewStreamableHTTPServerTransportAndHandler: %+v", err)
+	}
+	server, err := NewServer(svr)
+	if err != nil {
+		t.Fatalf("NewServer: %+v", err)
+	}
+	started := make(chan struct{})
+	server.RegisterTool(&protocol.Tool{Name: "slow", InputSchema: protocol.InputSchema{Type: protocol.Object}},
+		func(ctx context.Context, _ *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+			close(started)
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+
+	post := func(sessionID string, message interface{}) *httptest.ResponseRecorder {
+		body, err := json.Marshal(message)
+		if err != nil {
+			t.Errorf("json Marshal: %+v", err)
+			return nil
+		}
+		req := httptest.NewRequest(http.MethodPost, "/mcp", bytes.NewReader(body))
+		req.Header.Set("Accept", "application/json, text/event-stream")
+		if sessionID != "" {
+			req.Header.Set("Mcp-Session-Id", sessionID)
+			req.Header.Set("Mcp-Protocol-Version", protocol.Version)
+		}
+		w := httptest.NewRecorder()
+		handler.HandleMCP().ServeHTTP(w, req)
+		return w
+	}
+
+	w := post("", protocol.NewJSONRPCRequest(1, protocol.Initialize, protocol.InitializeRequest{ProtocolVersion: protocol.Version}))
+	sessionID := w.Header().Get("Mcp-Session-Id")
+	if w.Code != http.StatusOK || sessionID == "" {
+		t.Fatalf("initialize not as expected: status %d, body=%s", w.Code, w.Body.String())
+	}
+	if w = post(sessionID, protocol.NewJSONRPCNotification(protocol.NotificationInitialized, nil)); w.Code != http.StatusAccepted {
+		t.Fatalf("initialized not as expected: status %d, body=%s", w.Code, w.Body.String())
+	}
+
+	callDone := make(chan *httptest.ResponseRecorder, 1)
+	go func() {
+		callDone <- post(sessionID, protocol.NewJSONRPCRequest(2, protocol.ToolsCall, protocol.NewCallToolRequest("slow", nil)))
+	}()
+	<-started
+	if w = post(sessionID, protocol.NewJSONRPCNotification(protocol.NotificationCancelled, protocol.NewCancelledNotification(2, "user gave up"))); w.Code != http.StatusAccepted {
+		t.Fatalf("cancelled not as expected: status %d, body=%s", w.Code, w.Body.String())
+	}
+
+	select {
+	case w = <-callDone:
+		if w.Code != http.StatusAccepted || w.Body.Len() != 0 {
+			t.Fatalf("canceled call not as expected: status %d, body=%s", w.Code, w.Body.String())
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("canceled call did not return")
+	}
+}
+
+func TestServerCompleteUnknownReference(t *testing.T) {
+	reader, writer := io.Pipe()
+	defer reader.Close()
+
+	server, err := NewServer(transport.NewMockServerTransport(reader, writer))
+	if err != nil {
+		t.Fatalf("NewServer: %+v", err)
+	}
+
+	tests := []struct {
+		params    string
+		wantError string
+	}{
+		{params: `{"ref":{"type":"ref/tool","name":"t"},"argument":{"name":"a","value":"b"}}`, wantError: `unknown reference type "ref/tool"`},
+		{params: `{"argument":{"name":"a","value":"b"}}`, wantError: "reference is missing"},
+	}
+	for _, tt := range tests {
+		resp := server.receiveRequest(context.Background(), "", &protocol.JSONRPCRequest{ID: "1", Method: protocol.CompletionComplete, RawParams: json.RawMessage(tt.params)})
+		if resp.Error == nil || resp.Error.Code != protocol.InvalidParams || !strings.Contains(resp.Error.Message, tt.wantError) {
+			t.Fatalf("%s: expected invalid params error, got %+v", tt.params, resp.Error)
+		}
+	}
+}
@@ -65,6 +65,14 @@ func (m *Manager) GetSession(sessionID string) (*State, bool) {
 	return state, true
 }
 
+func (m *Manager) GetProtocolVersion(sessionID string) string {
+	state, has := m.GetSession(sessionID)
+	if !has {
+		return ""
+	}
+	return state.GetProtocolVersion()
+}
+
 func (m *Manager) OpenMessageQueueForSend(sessionID string) error {
 	state, has := m.GetSession(sessionID)
 	if !has {
@@ -126,7 +134,7 @@ func (m *Manager) StartHeartbeatAndCleanInvalidSessions() {
 		case <-ticker.C:
 			now := time.Now()
 			m.activeSessions.Range(func(sessionID string, state *State) bool {
-				if m.maxIdleTime != 0 && now.Sub(state.lastActiveAt) > m.maxIdleTime {
+				if m.maxIdleTime != 0 && now.Sub(state.getLastActiveAt()) > m.maxIdleTime {
 					m.logger.Infof("session expire, session id: %v", sessionID)
 					m.CloseSession(sessionID)
 					return true
@@ -16,7 +16,8 @@ import (
 var ErrQueueNotOpened = errors.New("queue has not been opened")
 
 type State struct {
-	lastActiveAt time.Time
+	// lastActiveAt is in unix nanoseconds, it is updated concurrently by the requests of a batch
+	lastActiveAt int64
 
 	mu       sync.RWMutex
 	sendChan chan []byte
@@ -25,6 +26,9 @@ type State struct {
 
 	reqID2respChan cmap.ConcurrentMap[string, chan *protocol.JSONRPCResponse]
 
+	// cancel funcs of the client requests being handled, keyed by request id
+	inFlightRequests cmap.ConcurrentMap[string, context.CancelFunc]
+
 	// cache client initialize request info
 	clientInfo         *protocol.Implementation
 	clientCapabilities *protocol.ClientCapabilities
@@ -32,20 +36,31 @@ type State struct {
 	// subscribed resources
 	subscribedResources cmap.ConcurrentMap[string, struct{}]
 
+	// minimum level of log messages the client wants to receive
+	loggingLevel *pkg.AtomicString
+
+	// protocol version negotiated during initialization
+	protocolVersion *pkg.AtomicString
+
 	receivedInitRequest *pkg.AtomicBool
 	ready               *pkg.AtomicBool
 	closed              *pkg.AtomicBool
 }
 
 func NewState() *State {
-	return &State{
-		lastActiveAt:        time.Now(),
+	state := &State{
+		lastActiveAt:        time.Now().UnixNano(),
 		reqID2respChan:      cmap.New[chan *protocol.JSONRPCResponse](),
+		inFlightRequests:    cmap.New[context.CancelFunc](),
 		subscribedResources: cmap.New[struct{}](),
+		loggingLevel:        pkg.NewAtomicString(),
+		protocolVersion:     pkg.NewAtomicString(),
 		receivedInitRequest: pkg.NewAtomicBool(),
 		ready:               pkg.NewAtomicBool(),
 		closed:              pkg.NewAtomicBool(),
 	}
+	state.loggingLevel.Store(string(protocol.LogInfo))
+	return state
 }
 
 func (s *State) SetClientInfo(ClientInfo *protocol.Implementation, ClientCapabilities *protocol.ClientCapabilities) {
@@ -57,6 +72,22 @@ func (s *State) GetClientCapabilities() *protocol.ClientCapabilities {
 	return s.clientCapabilities
 }
 
+func (s *State) SetLoggingLevel(level protocol.LoggingLevel) {
+	s.loggingLevel.Store(string(level))
+}
+
+func (s *State) GetLoggingLevel() protocol.LoggingLevel {
+	return protocol.LoggingLevel(s.loggingLevel.Load())
+}
+
+func (s *State) SetProtocolVersion(version string) {
+	s.protocolVersion.Store(version)
+}
+
+func (s *State) GetProtocolVersion() string {
+	return s.protocolVersion.Load()
+}
+
 func (s *State) SetReceivedInitRequest() {
 	s.receivedInitRequest.Store(true)
 }
@@ -81,6 +112,10 @@ func (s *State) GetReqID2respChan() cmap.ConcurrentMap[string, chan *protocol.JS
 	return s.reqID2respChan
 }
 
+func (s *State) GetInFlightRequests() cmap.ConcurrentMap[string, context.CancelFunc] {
+	return s.inFlightRequests
+}
+
 func (s *State) GetSubscribedResources() cmap.ConcurrentMap[string, struct{}] {
 	return s.subscribedResources
 }
@@ -97,7 +132,11 @@ func (s *State) Close() {
 }
 
 func (s *State) updateLastActiveAt() {
-	s.lastActiveAt = time.Now()
+	atomic.StoreInt64(&s.lastActiveAt, time.Now().UnixNano())
+}
+
+func (s *State) getLastActiveAt() time.Time {
+	return time.Unix(0, atomic.LoadInt64(&s.lastActiveAt))
 }
 
 func (s *State) openMessageQueueForSend() {
@@ -0,0 +1,32 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ThinkInAIXYZ/go-mcp/pkg"
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+)
+
+// TypedPromptHandlerFunc handles a prompts/get whose arguments have already been checked and decoded into Args
+type TypedPromptHandlerFunc[Args any] func(ctx context.Context, args Args) (*protocol.GetPromptResult, error)
+
+// RegisterTypedPrompt registers a prompt whose arguments are built from the fields of Args (see protocol.NewPromptArguments).
+// The arguments of each prompts/get are decoded into Args before handler is called,
+// a missing required argument or a value that doesn't fit its field is rejected with an invalid params error.
+func RegisterTypedPrompt[Args any](server *Server, name, description string, handler TypedPromptHandlerFunc[Args]) error {
+	arguments, err := protocol.NewPromptArguments(new(Args))
+	if err != nil {
+		return err
+	}
+
+	prompt := &protocol.Prompt{Name: name, Description: description, Arguments: arguments}
+	server.RegisterPrompt(prompt, func(ctx context.Context, request *protocol.GetPromptRequest) (*protocol.GetPromptResult, error) {
+		var args Args
+		if err := protocol.UnmarshalPromptArguments(request.Arguments, &args); err != nil {
+			return nil, fmt.Errorf("%w: prompt %s: %v", pkg.ErrInvalidParams, name, err)
+		}
+		return handler(ctx, args)
+	})
+	return nil
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/ThinkInAIXYZ/go-mcp/pkg"
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+)
+
+// TypedToolHandlerFunc handles a tool call whose arguments have already been validated and decoded into Req
+type TypedToolHandlerFunc[Req, Resp any] func(ctx context.Context, req Req) (Resp, error)
+
+// RegisterTypedTool registers a tool whose input schema is generated from Req.
+// The arguments of each call are validated against that schema (see RegisterTool) and decoded into Req before handler is called.
+//
+// The Resp returned by handler is converted into the call result:
+//   - protocol.CallToolResult and *protocol.CallToolResult are returned as is
+//   - a nil pointer becomes an error result, as the tool had nothing to return
+//   - string becomes a text content, []protocol.Content becomes the content
+//   - a struct, or a pointer to one, becomes the structured content, its output schema is generated from Resp
+//   - anything else is returned as its JSON encoding in a text content
+func RegisterTypedTool[Req, Resp any](server *Server, name, description string, handler TypedToolHandlerFunc[Req, Resp], opts ...protocol.ToolOption) error {
+	if hasStructuredOutput(reflect.TypeOf(new(Resp)).Elem()) {
+		outputSchema, err := protocol.GenerateOutputSchema(new(Resp))
+		if err != nil {
+			return err
+		}
+		opts = append([]protocol.ToolOption{func(t *protocol.Tool) { t.OutputSchema = outputSchema }}, opts...)
+	}
+
+	tool, err := protocol.NewTool(name, description, new(Req), opts...)
+	if err != nil {
+		return err
+	}
+
+	return server.TryRegisterTool(tool, func(ctx context.Context, request *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+		arguments := request.RawArguments
+		if len(arguments) == 0 {
+			arguments = json.RawMessage("{}")
+		}
+
+		var req Req
+		if err := pkg.JSONUnmarshal(arguments, &req); err != nil {
+			return nil, fmt.Errorf("%w: tool %s: %v", pkg.ErrInvalidParams, name, err)
+		}
+
+		resp, err := handler(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return newTypedToolResult(tool, resp)
+	})
+}
+
+func newTypedToolResult(tool *protocol.Tool, resp interface{}) (*protocol.CallToolResult, error) {
+	if rv := reflect.ValueOf(resp); resp == nil || (rv.Kind() == reflect.Ptr && rv.IsNil()) {
+		text := fmt.Sprintf("tool %s returned no result", tool.Name)
+		return protocol.NewCallToolResult([]protocol.Content{&protocol.TextContent{Type: "text", Text: text}}, true), nil
+	}
+
+	switch r := resp.(type) {
+	case *protocol.CallToolResult:
+		return r, nil
+	case protocol.CallToolResult:
+		return &r, nil
+	case string:
+		return protocol.NewCallToolResult([]protocol.Content{&protocol.TextContent{Type: "text", Text: r}}, false), nil
+	case []protocol.Content:
+		return protocol.NewCallToolResult(r, false), nil
+	}
+
+	if tool.HasOutputSchema() {
+		return protocol.NewCallToolResultWithStructuredContent(resp, false)
+	}
+
+	text, err := json.Marshal(resp)
+	if err != nil {
+		return nil, fmt.Errorf("marshal tool %s result: %w", tool.Name, err)
+	}
+	return protocol.NewCallToolResult([]protocol.Content{&protocol.TextContent{Type: "text", Text: string(text)}}, false), nil
+}
+
+// hasStructuredOutput reports whether the output schema of a typed tool can be generated from t
+func hasStructuredOutput(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && t != reflect.TypeOf(protocol.CallToolResult{})
+}
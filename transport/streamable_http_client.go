@@ -15,7 +15,11 @@ import (
 	"github.com/ThinkInAIXYZ/go-mcp/pkg"
 )
 
-const sessionIDHeader = "Mcp-Session-Id"
+const (
+	sessionIDHeader = "Mcp-Session-Id"
+	// protocolVersionHeader carries the negotiated protocol version on every request after initialization
+	protocolVersionHeader = "Mcp-Protocol-Version"
+)
 
 // const eventIDHeader = "Last-Event-ID"
 
@@ -47,6 +51,8 @@ type streamableHTTPClientTransport struct {
 	receiver  clientReceiver
 	sessionID *pkg.AtomicString
 
+	protocolVersion *pkg.AtomicString
+
 	// options
 	logger         pkg.Logger
 	receiveTimeout time.Duration
@@ -64,13 +70,14 @@ func NewStreamableHTTPClientTransport(serverURL string, opts ...StreamableHTTPCl
 	ctx, cancel := context.WithCancel(context.Background())
 
 	t := &streamableHTTPClientTransport{
-		ctx:            ctx,
-		cancel:         cancel,
-		serverURL:      parsedURL,
-		sessionID:      pkg.NewAtomicString(),
-		logger:         pkg.DefaultLogger,
-		receiveTimeout: time.Second * 30,
-		client:         http.DefaultClient,
+		ctx:             ctx,
+		cancel:          cancel,
+		serverURL:       parsedURL,
+		sessionID:       pkg.NewAtomicString(),
+		protocolVersion: pkg.NewAtomicString(),
+		logger:          pkg.DefaultLogger,
+		receiveTimeout:  time.Second * 30,
+		client:          http.DefaultClient,
 	}
 
 	for _, opt := range opts {
@@ -104,6 +111,7 @@ func (t *streamableHTTPClientTransport) Send(ctx context.Context, msg Message) e
 	if sessionID := t.sessionID.Load(); sessionID != "" {
 		req.Header.Set(sessionIDHeader, sessionID)
 	}
+	t.setProtocolVersionHeader(req)
 
 	resp, err := t.client.Do(req) //nolint:bodyclose
 	if err != nil {
@@ -182,6 +190,7 @@ func (t *streamableHTTPClientTransport) startSSEStream() {
 
 			req.Header.Set("Accept", "text/event-stream")
 			req.Header.Set(sessionIDHeader, sessionID)
+			t.setProtocolVersionHeader(req)
 
 			resp, err := t.client.Do(req)
 			if err != nil {
@@ -265,6 +274,17 @@ func (t *streamableHTTPClientTransport) SetReceiver(receiver clientReceiver) {
 	t.receiver = receiver
 }
 
+// SetProtocolVersion is called by the client once the protocol version has been negotiated
+func (t *streamableHTTPClientTransport) SetProtocolVersion(version string) {
+	t.protocolVersion.Store(version)
+}
+
+func (t *streamableHTTPClientTransport) setProtocolVersionHeader(req *http.Request) {
+	if version := t.protocolVersion.Load(); version != "" {
+		req.Header.Set(protocolVersionHeader, version)
+	}
+}
+
 func (t *streamableHTTPClientTransport) Close() error {
 	t.cancel()
 
@@ -276,6 +296,7 @@ func (t *streamableHTTPClientTransport) Close() error {
 			return err
 		}
 		req.Header.Set(sessionIDHeader, sessionID)
+		t.setProtocolVersionHeader(req)
 		resp, err := t.client.Do(req)
 		if err != nil {
 			return fmt.Errorf("failed to send message: %w", err)
@@ -9,8 +9,11 @@ import (
 	"net/http"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/tidwall/gjson"
+
 	"github.com/ThinkInAIXYZ/go-mcp/pkg"
 	"github.com/ThinkInAIXYZ/go-mcp/protocol"
 )
@@ -28,6 +31,22 @@ type SessionIDForReturn struct {
 	SessionID string
 }
 
+type RequestCanceledKey struct{}
+
+// RequestCanceled is marked by the receiver when the client canceled the request with notifications/cancelled,
+// its output channel is then closed without a response, as it is on failure.
+type RequestCanceled struct {
+	canceled int32
+}
+
+func (c *RequestCanceled) Cancel() {
+	atomic.StoreInt32(&c.canceled, 1)
+}
+
+func (c *RequestCanceled) IsCanceled() bool {
+	return atomic.LoadInt32(&c.canceled) == 1
+}
+
 type StreamableHTTPServerTransportOption func(*streamableHTTPServerTransport)
 
 func WithStreamableHTTPServerTransportOptionLogger(logger pkg.Logger) StreamableHTTPServerTransportOption {
@@ -212,16 +231,32 @@ func (t *streamableHTTPServerTransport) handlePost(w http.ResponseWriter, r *htt
 		return
 	}
 
+	sessionID := r.Header.Get(sessionIDHeader)
+	isInitialize := gjson.GetBytes(bs, "method").String() == string(protocol.Initialize)
+	if t.stateMode == Stateful && sessionID == "" && !isInitialize {
+		t.writeError(w, http.StatusBadRequest, "Missing session ID")
+		return
+	}
+	if !isInitialize {
+		if err = t.checkProtocolVersion(r, sessionID); err != nil {
+			t.writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
 	// Disconnection SHOULD NOT be interpreted as the client canceling its request.
 	// To cancel, the client SHOULD explicitly send an MCP CancelledNotification.
 	ctx := pkg.NewCancelShieldContext(r.Context())
 
+	canceled := &RequestCanceled{}
+	ctx = context.WithValue(ctx, RequestCanceledKey{}, canceled)
+
 	// For InitializeRequest HTTP response
 	if t.stateMode == Stateful {
 		ctx = context.WithValue(ctx, SessionIDForReturnKey{}, &SessionIDForReturn{})
 	}
 
-	outputMsgCh, err := t.receiver.Receive(ctx, r.Header.Get(sessionIDHeader), bs)
+	outputMsgCh, err := t.receiver.Receive(ctx, sessionID, bs)
 	if err != nil {
 		if errors.Is(err, pkg.ErrSessionClosed) {
 			t.writeError(w, http.StatusNotFound, fmt.Sprintf("Failed to receive: %v", err))
@@ -239,6 +274,10 @@ func (t *streamableHTTPServerTransport) handlePost(w http.ResponseWriter, r *htt
 
 	msg := <-outputMsgCh
 	if len(msg) == 0 {
+		if canceled.IsCanceled() { // the client canceled the request, so no response is sent
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
 		t.writeError(w, http.StatusInternalServerError, "handle request fail")
 		return
 	}
@@ -289,6 +328,11 @@ func (t *streamableHTTPServerTransport) handleGet(w http.ResponseWriter, r *http
 		flusher.Flush()
 		return
 	}
+	if err := t.checkProtocolVersion(r, sessionID); err != nil {
+		t.writeError(w, http.StatusBadRequest, err.Error())
+		flusher.Flush()
+		return
+	}
 	if err := t.sessionManager.OpenMessageQueueForSend(sessionID); err != nil {
 		t.writeError(w, http.StatusBadRequest, err.Error())
 		flusher.Flush()
@@ -317,12 +361,35 @@ func (t *streamableHTTPServerTransport) handleGet(w http.ResponseWriter, r *http
 	}
 }
 
+// checkProtocolVersion validates the Mcp-Protocol-Version header against the version negotiated with the session.
+// A request without the header is accepted, the spec asks servers to fall back to the negotiated version (or 2025-03-26) for backwards compatibility.
+func (t *streamableHTTPServerTransport) checkProtocolVersion(r *http.Request, sessionID string) error {
+	version := r.Header.Get(protocolVersionHeader)
+	if version == "" {
+		return nil
+	}
+	if _, ok := protocol.SupportedVersion[version]; !ok {
+		return fmt.Errorf("unsupported protocol version: %s", version)
+	}
+	if sessionID == "" {
+		return nil
+	}
+	if negotiated := t.sessionManager.GetProtocolVersion(sessionID); negotiated != "" && negotiated != version {
+		return fmt.Errorf("protocol version %s does not match the negotiated version %s", version, negotiated)
+	}
+	return nil
+}
+
 func (t *streamableHTTPServerTransport) handleDelete(w http.ResponseWriter, r *http.Request) {
-	sessionID := r.Header.Get("Mcp-Session-Id")
+	sessionID := r.Header.Get(sessionIDHeader)
 	if sessionID == "" {
 		t.writeError(w, http.StatusBadRequest, "Missing session ID")
 		return
 	}
+	if err := t.checkProtocolVersion(r, sessionID); err != nil {
+		t.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
 	t.sessionManager.CloseSession(sessionID)
 	w.WriteHeader(http.StatusOK)
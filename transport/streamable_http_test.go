@@ -1,8 +1,14 @@
 package transport
 
 import (
+	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
 )
 
 func TestStreamableHTTP(t *testing.T) {
@@ -29,3 +35,88 @@ func TestStreamableHTTP(t *testing.T) {
 
 	testTransport(t, client, svr)
 }
+
+func TestStreamableHTTPProtocolVersionHeader(t *testing.T) {
+	svr, handler, err := NewStreamableHTTPServerTransportAndHandler(
+		WithStreamableHTTPServerTransportAndHandlerOptionStateMode(Stateful))
+	if err != nil {
+		t.Fatalf("NewStreamableHTTPServerTransportAndHandler failed: %v", err)
+	}
+	svr.SetReceiver(ServerReceiverF(func(context.Context, string, []byte) (<-chan []byte, error) {
+		return nil, nil
+	}))
+	svr.SetSessionManager(&mockSessionManager{protocolVersion: protocol.Version20250326})
+
+	tests := []struct {
+		name           string
+		sessionID      string
+		version        string
+		body           string
+		expectedStatus int
+	}{
+		{name: "matching_version", sessionID: "s1", version: protocol.Version20250326, body: `{"jsonrpc":"2.0","method":"notifications/initialized"}`, expectedStatus: http.StatusAccepted},
+		{name: "missing_version", sessionID: "s1", body: `{"jsonrpc":"2.0","method":"notifications/initialized"}`, expectedStatus: http.StatusAccepted},
+		{name: "mismatched_version", sessionID: "s1", version: protocol.Version20241105, body: `{"jsonrpc":"2.0","method":"notifications/initialized"}`, expectedStatus: http.StatusBadRequest},
+		{name: "unsupported_version", sessionID: "s1", version: "1999-01-01", body: `{"jsonrpc":"2.0","method":"notifications/initialized"}`, expectedStatus: http.StatusBadRequest},
+		{name: "missing_session", body: `{"jsonrpc":"2.0","method":"notifications/initialized"}`, expectedStatus: http.StatusBadRequest},
+		{name: "initialize_without_session", body: `{"jsonrpc":"2.0","id":1,"method":"initialize"}`, expectedStatus: http.StatusAccepted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(tt.body))
+			req.Header.Set("Accept", "application/json, text/event-stream")
+			if tt.sessionID != "" {
+				req.Header.Set(sessionIDHeader, tt.sessionID)
+			}
+			if tt.version != "" {
+				req.Header.Set(protocolVersionHeader, tt.version)
+			}
+
+			w := httptest.NewRecorder()
+			handler.HandleMCP().ServeHTTP(w, req)
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("status not as expected: got %d, want %d, body=%s", w.Code, tt.expectedStatus, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestStreamableHTTPCanceledRequest(t *testing.T) {
+	tests := []struct {
+		name       string
+		canceled   bool
+		wantStatus int
+	}{
+		{name: "canceled", canceled: true, wantStatus: http.StatusAccepted},
+		{name: "failed", canceled: false, wantStatus: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svr, handler, err := NewStreamableHTTPServerTransportAndHandler()
+			if err != nil {
+				t.Fatalf("NewStreamableHTTPServerTransportAndHandler failed: %v", err)
+			}
+			svr.SetReceiver(ServerReceiverF(func(ctx context.Context, _ string, _ []byte) (<-chan []byte, error) {
+				// both a canceled and a failed request close their channel without a response
+				if canceled, ok := ctx.Value(RequestCanceledKey{}).(*RequestCanceled); ok && tt.canceled {
+					canceled.Cancel()
+				}
+				ch := make(chan []byte)
+				close(ch)
+				return ch, nil
+			}))
+			svr.SetSessionManager(&mockSessionManager{})
+
+			req := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"slow"}}`))
+			req.Header.Set("Accept", "application/json, text/event-stream")
+			w := httptest.NewRecorder()
+			handler.HandleMCP().ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status not as expected: got %d, want %d, body=%s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.canceled && w.Body.Len() != 0 {
+				t.Fatalf("a canceled request should have no body: %s", w.Body.String())
+			}
+		})
+	}
+}
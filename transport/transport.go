@@ -32,6 +32,12 @@ type ClientTransport interface {
 	Close() error
 }
 
+// ProtocolVersionSetter is implemented by client transports that carry the negotiated protocol version themselves,
+// like streamable HTTP does with the Mcp-Protocol-Version header.
+type ProtocolVersionSetter interface {
+	SetProtocolVersion(version string)
+}
+
 type clientReceiver interface {
 	Receive(ctx context.Context, msg []byte) error
 }
@@ -83,4 +89,6 @@ type sessionManager interface {
 	DequeueMessageForSend(ctx context.Context, sessionID string) ([]byte, error)
 	CloseSession(sessionID string)
 	CloseAllSessions()
+	// GetProtocolVersion returns the protocol version negotiated with the session, empty if it is unknown
+	GetProtocolVersion(sessionID string) string
 }
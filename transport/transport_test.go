@@ -13,6 +13,8 @@ import (
 
 type mockSessionManager struct {
 	pkg.SyncMap[chan []byte]
+
+	protocolVersion string
 }
 
 func newMockSessionManager() *mockSessionManager {
@@ -79,6 +81,10 @@ func (m *mockSessionManager) CloseSession(sessionID string) {
 	close(ch)
 }
 
+func (m *mockSessionManager) GetProtocolVersion(string) string {
+	return m.protocolVersion
+}
+
 func (m *mockSessionManager) CloseAllSessions() {
 	m.Range(func(key string, value chan []byte) bool {
 		m.Delete(key)